@@ -0,0 +1,76 @@
+package clog
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock provides the current time to the handler. It can be replaced by WithClock to make output deterministic in tests.
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// processStart is the default start time of Elapsed when the system clock is used.
+var processStart = time.Now()
+
+// WithClock sets the clock of the handler. The clock is used for Timestamp and Elapsed in the template, and Elapsed starts from the time of the clock when the handler is created. Timestamp is the time of the clock when the record is handled instead of the time of the record, so that output through slog.Logger is deterministic, but a record with zero time still has no Timestamp. The default is the system clock, Timestamp is the time of the record, and Elapsed starts from the start of the program. If nil is passed, the system clock is used.
+func WithClock(clock Clock) Option {
+	return func(cfg *config) {
+		if clock != nil {
+			cfg.clock = clock
+			cfg.clockSpecified = true
+		}
+	}
+}
+
+// WithElapsedSince sets the start time of Elapsed in the template. It overrides the default start time decided by WithClock.
+func WithElapsedSince(t time.Time) Option {
+	return func(cfg *config) {
+		cfg.elapsedSince = t
+	}
+}
+
+// timing is time information of a record that is calculated once and shared by all renderings of the record.
+type timing struct {
+	// now is the time of the clock when the record is handled. It is zero if unknown.
+	now     time.Time
+	elapsed float64
+	delta   float64
+}
+
+// timing returns elapsed seconds since the start time and seconds since the previous record.
+func (x *config) timing() timing {
+	now, delta := x.delta.next(x.clock)
+	return timing{
+		now:     now,
+		elapsed: now.Sub(x.startTime).Seconds(),
+		delta:   delta.Seconds(),
+	}
+}
+
+// deltaTracker tracks the time of the previous record. It is shared by handlers derived from the same handler.
+type deltaTracker struct {
+	mutex sync.Mutex
+	prev  time.Time
+}
+
+// next returns the current time and the duration since the previous call. The current time is taken under the lock so that the duration is never negative with a monotonic clock.
+func (x *deltaTracker) next(clock Clock) (time.Time, time.Duration) {
+	x.mutex.Lock()
+	defer x.mutex.Unlock()
+
+	now := clock.Now()
+	if x.prev.IsZero() {
+		x.prev = now
+		return now, 0
+	}
+	delta := now.Sub(x.prev)
+	x.prev = now
+	return now, delta
+}
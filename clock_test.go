@@ -0,0 +1,95 @@
+package clog_test
+
+import (
+	"bytes"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"text/template"
+	"time"
+
+	"github.com/m-mizutani/clog"
+	"github.com/m-mizutani/gt"
+)
+
+func TestWithElapsedSince(t *testing.T) {
+	var buf bytes.Buffer
+	clock := &stepClock{now: goldenTime, step: time.Second}
+	logger := slog.New(clog.New(
+		clog.WithWriter(&buf),
+		clog.WithColor(false),
+		clog.WithClock(clock),
+		clog.WithElapsedSince(goldenTime.Add(-10*time.Second)),
+		clog.WithTemplate(template.Must(template.New("elapsed").Parse(`{{.Elapsed | printf "%.1f"}} {{.Message}}`))),
+	))
+
+	logger.Info("first")
+	logger.Info("second")
+	gt.S(t, buf.String()).Equal("10.0 first\n11.0 second\n")
+}
+
+func TestDelta(t *testing.T) {
+	var buf bytes.Buffer
+	clock := &stepClock{now: goldenTime, step: 1500 * time.Millisecond}
+	logger := slog.New(clog.New(
+		clog.WithWriter(&buf),
+		clog.WithColor(false),
+		clog.WithClock(clock),
+		clog.WithTemplate(template.Must(template.New("delta").Parse(clog.TemplateStandardWithDelta))),
+	))
+
+	logger.Info("first")
+	logger.WithGroup("g").Info("second")
+	logger.With("k", "v").Info("third")
+	gt.S(t, buf.String()).Equal(
+		"+  0.000 INFO first \n" +
+			"+  1.500 INFO second \n" +
+			"+  1.500 INFO third k=\"v\" \n")
+}
+
+func TestDeltaConcurrent(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(clog.New(
+		clog.WithWriter(&buf),
+		clog.WithColor(false),
+		clog.WithTemplate(template.Must(template.New("delta").Parse(`{{.Delta}}`))),
+	))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				logger.Info("hello")
+			}
+		}()
+	}
+	wg.Wait()
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	gt.A(t, lines).Length(800)
+	for _, line := range lines {
+		delta, err := strconv.ParseFloat(line, 64)
+		gt.NoError(t, err)
+		gt.N(t, delta).GreaterOrEqual(0)
+	}
+}
+
+func TestClockTimestamp(t *testing.T) {
+	var buf bytes.Buffer
+	clock := &stepClock{now: goldenTime, step: 1500 * time.Millisecond}
+	logger := slog.New(clog.New(
+		clog.WithWriter(&buf),
+		clog.WithColor(false),
+		clog.WithClock(clock),
+		clog.WithTemplate(template.Must(template.New("time").Parse(clog.TemplateStandardWithTime))),
+	))
+
+	// New reads the clock once for the start time. Records of slog.Logger have the current time, and Timestamp is taken from the clock instead
+	logger.Info("first")
+	logger.Info("second")
+	gt.S(t, buf.String()).Equal("03:04:07.178 INFO first \n03:04:08.678 INFO second \n")
+}
@@ -0,0 +1,239 @@
+// Package clogtest provides helpers to test code that logs through slog. Recorder captures records as structured data, so tests can assert attributes without parsing rendered text.
+package clogtest
+
+import (
+	"bytes"
+	"context"
+	"runtime"
+	"slices"
+	"sync"
+	"time"
+
+	"log/slog"
+
+	"github.com/m-mizutani/clog"
+)
+
+// Attr is a flattened attribute. Attributes in groups are flattened with their group path.
+type Attr struct {
+	// Path is a list of group names that contain the attribute.
+	Path []string
+
+	// Key is a key of the attribute.
+	Key string
+
+	// Value is a resolved value of the attribute. It is never a group or LogValuer.
+	Value slog.Value
+}
+
+// FullKey returns the key joined with the group path by ".", e.g. "req.path". It is the same as the key printed by clog.LinearPrinter.
+func (x Attr) FullKey() string {
+	return clog.LinearKey(x.Path, x.Key)
+}
+
+// Record is a captured log record.
+type Record struct {
+	Time    time.Time
+	Level   slog.Level
+	Message string
+
+	// Source is a source code location of the log call. It is nil if the record has no PC.
+	Source *slog.Source
+
+	// Attrs is a list of flattened attributes including ones added by WithAttrs.
+	Attrs []Attr
+
+	ctx    context.Context
+	raw    slog.Record
+	chains []chain
+}
+
+// Attr returns the value of the attribute that has the full key, e.g. "req.path". If multiple attributes have the key, the last one is returned.
+func (x Record) Attr(fullKey string) (slog.Value, bool) {
+	for i := len(x.Attrs) - 1; i >= 0; i-- {
+		if x.Attrs[i].FullKey() == fullKey {
+			return x.Attrs[i].Value, true
+		}
+	}
+	return slog.Value{}, false
+}
+
+// chain is a WithGroup or WithAttrs call on Recorder. It is replayed to render records with clog.
+type chain struct {
+	group string
+	attrs []slog.Attr
+}
+
+type recorderState struct {
+	mutex   sync.Mutex
+	records []Record
+}
+
+// Recorder is a slog.Handler that captures all records. Handlers derived by WithAttrs and WithGroup share captured records with the original Recorder. Recorder is safe for concurrent use.
+type Recorder struct {
+	state  *recorderState
+	chains []chain
+}
+
+var _ slog.Handler = (*Recorder)(nil)
+
+// NewRecorder creates a Recorder. It can be combined with other handlers by clog.Fanout.
+func NewRecorder() *Recorder {
+	return &Recorder{
+		state: &recorderState{},
+	}
+}
+
+// Enabled implements slog.Handler. Recorder captures records of all levels.
+func (x *Recorder) Enabled(ctx context.Context, level slog.Level) bool {
+	return true
+}
+
+// Handle implements slog.Handler.
+func (x *Recorder) Handle(ctx context.Context, record slog.Record) error {
+	rec := Record{
+		Time:    record.Time,
+		Level:   record.Level,
+		Message: record.Message,
+		ctx:     ctx,
+		raw:     record.Clone(),
+		chains:  x.chains,
+	}
+
+	if record.PC != 0 {
+		fs := runtime.CallersFrames([]uintptr{record.PC})
+		f, _ := fs.Next()
+		rec.Source = &slog.Source{
+			Function: f.Function,
+			File:     f.File,
+			Line:     f.Line,
+		}
+	}
+
+	var groups []string
+	for _, c := range x.chains {
+		if c.group != "" {
+			groups = append(groups, c.group)
+		}
+		rec.Attrs = appendFlattened(rec.Attrs, groups, c.attrs)
+	}
+	var attrs []slog.Attr
+	record.Attrs(func(attr slog.Attr) bool {
+		attrs = append(attrs, attr)
+		return true
+	})
+	rec.Attrs = appendFlattened(rec.Attrs, groups, attrs)
+
+	x.state.mutex.Lock()
+	defer x.state.mutex.Unlock()
+	x.state.records = append(x.state.records, rec)
+	return nil
+}
+
+// appendFlattened resolves attrs and appends them to dst with the group path. Empty attributes are ignored and groups with empty key are inlined according to slog.Handler rules.
+func appendFlattened(dst []Attr, groups []string, attrs []slog.Attr) []Attr {
+	for _, attr := range attrs {
+		value := attr.Value.Resolve()
+		if attr.Key == "" && value.Kind() != slog.KindGroup {
+			continue
+		}
+
+		if value.Kind() == slog.KindGroup {
+			path := groups
+			if attr.Key != "" {
+				path = append(slices.Clone(groups), attr.Key)
+			}
+			dst = appendFlattened(dst, path, value.Group())
+			continue
+		}
+
+		dst = append(dst, Attr{
+			Path:  slices.Clone(groups),
+			Key:   attr.Key,
+			Value: value,
+		})
+	}
+	return dst
+}
+
+// WithAttrs implements slog.Handler.
+func (x *Recorder) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &Recorder{
+		state:  x.state,
+		chains: append(slices.Clip(x.chains), chain{attrs: attrs}),
+	}
+}
+
+// WithGroup implements slog.Handler.
+func (x *Recorder) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return x
+	}
+	return &Recorder{
+		state:  x.state,
+		chains: append(slices.Clip(x.chains), chain{group: name}),
+	}
+}
+
+// Records returns all captured records.
+func (x *Recorder) Records() []Record {
+	x.state.mutex.Lock()
+	defer x.state.mutex.Unlock()
+	return slices.Clone(x.state.records)
+}
+
+// FindByMessage returns captured records that have the message.
+func (x *Recorder) FindByMessage(msg string) []Record {
+	var found []Record
+	for _, rec := range x.Records() {
+		if rec.Message == msg {
+			found = append(found, rec)
+		}
+	}
+	return found
+}
+
+// AttrsOf returns attributes of the first captured record that has the message. The map is keyed by full key such as "req.path" and has values of slog.Value.Any(). It returns nil if no record has the message.
+func (x *Recorder) AttrsOf(msg string) map[string]any {
+	found := x.FindByMessage(msg)
+	if len(found) == 0 {
+		return nil
+	}
+
+	attrs := make(map[string]any, len(found[0].Attrs))
+	for _, attr := range found[0].Attrs {
+		attrs[attr.FullKey()] = attr.Value.Any()
+	}
+	return attrs
+}
+
+// Reset discards all captured records.
+func (x *Recorder) Reset() {
+	x.state.mutex.Lock()
+	defer x.state.mutex.Unlock()
+	x.state.records = nil
+}
+
+// Render renders all captured records through clog.Handler and returns the output. Color is disabled unless options enable it. It is useful for failure messages of tests.
+func (x *Recorder) Render(options ...clog.Option) string {
+	var buf bytes.Buffer
+	opts := append([]clog.Option{
+		clog.WithWriter(&buf),
+		clog.WithColor(false),
+		clog.WithLevel(slog.LevelDebug),
+	}, options...)
+
+	base := clog.New(opts...)
+	for _, rec := range x.Records() {
+		var h slog.Handler = base
+		for _, c := range rec.chains {
+			if c.group != "" {
+				h = h.WithGroup(c.group)
+			} else {
+				h = h.WithAttrs(c.attrs)
+			}
+		}
+		_ = h.Handle(rec.ctx, rec.raw)
+	}
+	return buf.String()
+}
@@ -0,0 +1,86 @@
+package clogtest_test
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+
+	"github.com/m-mizutani/clog"
+	"github.com/m-mizutani/clog/clogtest"
+	"github.com/m-mizutani/gt"
+)
+
+type user struct {
+	name string
+}
+
+func (x user) LogValue() slog.Value {
+	return slog.GroupValue(slog.String("name", x.name))
+}
+
+func TestRecorder(t *testing.T) {
+	rec := clogtest.NewRecorder()
+	logger := slog.New(rec)
+
+	logger.With("service", "api").WithGroup("req").Info("handled",
+		slog.String("path", "/users"),
+		slog.Int("status", 200),
+		slog.Any("user", user{name: "alice"}),
+		slog.Group("", slog.String("inlined", "yes")),
+	)
+	logger.Debug("debug message")
+
+	records := rec.Records()
+	gt.A(t, records).Length(2)
+	gt.V(t, records[0].Level).Equal(slog.LevelInfo)
+	gt.V(t, records[0].Message).Equal("handled")
+	gt.V(t, records[0].Source).NotNil()
+	gt.S(t, records[0].Source.File).Contains("recorder_test.go")
+
+	attrs := rec.AttrsOf("handled")
+	gt.V(t, attrs).Equal(map[string]any{
+		"service":       "api",
+		"req.path":      "/users",
+		"req.status":    int64(200),
+		"req.user.name": "alice",
+		"req.inlined":   "yes",
+	})
+
+	v, ok := records[0].Attr("req.status")
+	gt.B(t, ok).True()
+	gt.N(t, v.Int64()).Equal(200)
+
+	gt.A(t, rec.FindByMessage("debug message")).Length(1)
+	gt.A(t, rec.FindByMessage("no such message")).Length(0)
+	gt.V(t, rec.AttrsOf("no such message")).Nil()
+
+	rec.Reset()
+	gt.A(t, rec.Records()).Length(0)
+}
+
+func TestRecorderRender(t *testing.T) {
+	rec := clogtest.NewRecorder()
+	logger := slog.New(rec)
+
+	logger.With("service", "api").WithGroup("req").Info("handled", slog.String("path", "/users"))
+	logger.Debug("debug message")
+
+	gt.S(t, rec.Render()).
+		Contains(`INFO handled service="api" req.path="/users"`).
+		Contains("DEBUG debug message")
+	gt.S(t, rec.Render(clog.WithPrinter(clog.IndentPrinter))).
+		Contains("\n  path: \"/users\"")
+}
+
+func TestRecorderWithFanout(t *testing.T) {
+	var buf bytes.Buffer
+	rec := clogtest.NewRecorder()
+	logger := slog.New(clog.Fanout(
+		clog.New(clog.WithWriter(&buf), clog.WithColor(false)),
+		rec,
+	))
+
+	logger.Info("hello", slog.String("foo", "bar"))
+	gt.S(t, buf.String()).Contains(`foo="bar"`)
+	gt.V(t, rec.AttrsOf("hello")["foo"]).Equal("bar")
+}
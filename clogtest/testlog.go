@@ -0,0 +1,50 @@
+package clogtest
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/m-mizutani/clog"
+)
+
+// NewTest creates a handler that writes each record to t.Log, so logs of code under test are shown with the test instead of interleaving on stdout. Color is disabled and source is enabled by default, and they can be changed by options. Records handled after the test finishes are discarded to avoid panic of testing package.
+//
+// The location that testing prints before each line is in clog, because the caller of the logger can't be marked by t.Helper. The caller is printed by the source of the record in the template instead, e.g. "[service.go:42]".
+func NewTest(t testing.TB, options ...clog.Option) *clog.Handler {
+	w := &testWriter{t: t}
+	t.Cleanup(w.stop)
+
+	opts := append([]clog.Option{
+		clog.WithColor(false),
+		clog.WithSource(true),
+	}, options...)
+	opts = append(opts, clog.WithWriter(w))
+
+	return clog.New(opts...)
+}
+
+// testWriter is an io.Writer that writes each line to t.Log.
+type testWriter struct {
+	t       testing.TB
+	mutex   sync.Mutex
+	stopped bool
+}
+
+func (x *testWriter) Write(p []byte) (int, error) {
+	// the lock is held while t.Log to prevent stop between the check and t.Log
+	x.mutex.Lock()
+	defer x.mutex.Unlock()
+	if x.stopped {
+		return len(p), nil
+	}
+
+	x.t.Log(strings.TrimSuffix(string(p), "\n"))
+	return len(p), nil
+}
+
+func (x *testWriter) stop() {
+	x.mutex.Lock()
+	defer x.mutex.Unlock()
+	x.stopped = true
+}
@@ -0,0 +1,57 @@
+package clogtest_test
+
+import (
+	"fmt"
+	"log/slog"
+	"testing"
+
+	"github.com/m-mizutani/clog"
+	"github.com/m-mizutani/clog/clogtest"
+	"github.com/m-mizutani/gt"
+)
+
+type fakeTB struct {
+	testing.TB
+	logs     []string
+	cleanups []func()
+}
+
+func (x *fakeTB) Helper() {}
+
+func (x *fakeTB) Log(args ...any) {
+	x.logs = append(x.logs, fmt.Sprint(args...))
+}
+
+func (x *fakeTB) Cleanup(f func()) {
+	x.cleanups = append(x.cleanups, f)
+}
+
+func TestNewTest(t *testing.T) {
+	tb := &fakeTB{TB: t}
+	logger := slog.New(clogtest.NewTest(tb, clog.WithLevel(slog.LevelDebug)))
+
+	logger.Debug("hello, world!", slog.String("foo", "bar"))
+	gt.A(t, tb.logs).Length(1)
+	gt.S(t, tb.logs[0]).
+		Contains("DEBUG [testlog_test.go:").
+		Contains(`hello, world! foo="bar"`).
+		NotContains("\n").
+		NotContains("\x1b[")
+
+	for _, f := range tb.cleanups {
+		f()
+	}
+	logger.Info("after test")
+	gt.A(t, tb.logs).Length(1)
+}
+
+func TestNewTestAfterCompleted(t *testing.T) {
+	var logger *slog.Logger
+	t.Run("subtest", func(t *testing.T) {
+		logger = slog.New(clogtest.NewTest(t))
+		logger.Info("logged via t.Log", slog.Int("n", 1))
+	})
+
+	// t.Log of the completed subtest panics, so the record must be discarded
+	logger.Info("after subtest")
+}
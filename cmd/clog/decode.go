@@ -0,0 +1,214 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"time"
+
+	"log/slog"
+
+	"github.com/m-mizutani/clog/internal/decoded"
+	"github.com/m-mizutani/clog/logfmt"
+	"github.com/m-mizutani/goerr/v2"
+)
+
+// decoders are functions to rebuild records for each input format.
+var decoders = map[string]func(line []byte) (slog.Record, bool){
+	"json":   decodeRecord,
+	"logfmt": decodeLogfmt,
+	"auto": func(line []byte) (slog.Record, bool) {
+		if record, ok := decodeRecord(line); ok {
+			return record, true
+		}
+		return decodeLogfmt(line)
+	},
+}
+
+// decodeLogfmt rebuilds a record from a logfmt line or clog's output. Dotted keys are rebuilt as groups.
+func decodeLogfmt(line []byte) (slog.Record, bool) {
+	record, err := logfmt.Parse(string(line))
+	return record, err == nil
+}
+
+// decodeRecord rebuilds a record from a JSON line written by slog.JSONHandler. Nested objects are rebuilt as groups in the original order. It returns false if the line is not a JSON object or has no message.
+func decodeRecord(line []byte) (slog.Record, bool) {
+	line = bytes.TrimSpace(line)
+	if len(line) == 0 || line[0] != '{' {
+		return slog.Record{}, false
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(line))
+	dec.UseNumber()
+	if _, err := dec.Token(); err != nil {
+		return slog.Record{}, false
+	}
+	attrs, err := decodeObject(dec)
+	if err != nil {
+		return slog.Record{}, false
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		// trailing data after the object
+		return slog.Record{}, false
+	}
+
+	var (
+		ts       time.Time
+		level    = slog.LevelInfo
+		msg      string
+		hasMsg   bool
+		remained []slog.Attr
+	)
+	for _, attr := range attrs {
+		switch attr.Key {
+		case slog.TimeKey:
+			if t, err := time.Parse(time.RFC3339Nano, attr.Value.String()); err == nil && attr.Value.Kind() == slog.KindString {
+				ts = t
+				continue
+			}
+		case slog.LevelKey:
+			var lv slog.Level
+			if err := lv.UnmarshalText([]byte(attr.Value.String())); err == nil && attr.Value.Kind() == slog.KindString {
+				level = lv
+				continue
+			}
+		case slog.MessageKey:
+			if attr.Value.Kind() == slog.KindString {
+				msg, hasMsg = attr.Value.String(), true
+				continue
+			}
+		case slog.SourceKey:
+			if src, ok := decodeSource(attr.Value); ok {
+				attr = slog.Any(slog.SourceKey, src)
+			}
+		}
+		remained = append(remained, attr)
+	}
+	if !hasMsg {
+		return slog.Record{}, false
+	}
+
+	record := slog.NewRecord(ts, level, msg, 0)
+	record.AddAttrs(remained...)
+	return record, true
+}
+
+// withSource moves the source attribute of the decoded record to ctx, so that clog.Handler prints it as the source location of the record without PC.
+func withSource(ctx context.Context, record slog.Record) (context.Context, slog.Record) {
+	var src *slog.Source
+	var attrs []slog.Attr
+	record.Attrs(func(attr slog.Attr) bool {
+		if s, ok := attr.Value.Any().(*slog.Source); ok && attr.Key == slog.SourceKey && src == nil {
+			src = s
+			return true
+		}
+		attrs = append(attrs, attr)
+		return true
+	})
+	if src == nil {
+		return ctx, record
+	}
+
+	rebuilt := slog.NewRecord(record.Time, record.Level, record.Message, record.PC)
+	rebuilt.AddAttrs(attrs...)
+	return decoded.WithSource(ctx, src), rebuilt
+}
+
+// decodeSource converts a group of "function", "file" and "line" to *slog.Source.
+func decodeSource(value slog.Value) (*slog.Source, bool) {
+	if value.Kind() != slog.KindGroup {
+		return nil, false
+	}
+
+	var src slog.Source
+	for _, attr := range value.Group() {
+		switch attr.Key {
+		case "function":
+			src.Function = attr.Value.String()
+		case "file":
+			src.File = attr.Value.String()
+		case "line":
+			src.Line = int(attr.Value.Int64())
+		}
+	}
+	if src.File == "" {
+		return nil, false
+	}
+	return &src, true
+}
+
+// decodeObject decodes members of an object after its opening brace, and consumes the closing brace.
+func decodeObject(dec *json.Decoder) ([]slog.Attr, error) {
+	var attrs []slog.Attr
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, goerr.Wrap(err, "failed to read key")
+		}
+		key, ok := tok.(string)
+		if !ok {
+			return nil, goerr.New("key is not a string", goerr.V("token", tok))
+		}
+
+		value, err := decodeValue(dec)
+		if err != nil {
+			return nil, err
+		}
+		attrs = append(attrs, slog.Attr{Key: key, Value: value})
+	}
+	if _, err := dec.Token(); err != nil {
+		return nil, goerr.Wrap(err, "failed to read end of object")
+	}
+	return attrs, nil
+}
+
+// decodeValue decodes a value. An object is decoded as a group and an array as []any.
+func decodeValue(dec *json.Decoder) (slog.Value, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return slog.Value{}, goerr.Wrap(err, "failed to read value")
+	}
+
+	switch v := tok.(type) {
+	case json.Delim:
+		switch v {
+		case '{':
+			attrs, err := decodeObject(dec)
+			if err != nil {
+				return slog.Value{}, err
+			}
+			return slog.GroupValue(attrs...), nil
+		case '[':
+			var values []any
+			for dec.More() {
+				value, err := decodeValue(dec)
+				if err != nil {
+					return slog.Value{}, err
+				}
+				values = append(values, value.Any())
+			}
+			if _, err := dec.Token(); err != nil {
+				return slog.Value{}, goerr.Wrap(err, "failed to read end of array")
+			}
+			return slog.AnyValue(values), nil
+		}
+		return slog.Value{}, goerr.New("unexpected delimiter", goerr.V("delim", v))
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return slog.Int64Value(i), nil
+		}
+		f, err := v.Float64()
+		if err != nil {
+			return slog.Value{}, goerr.Wrap(err, "invalid number", goerr.V("number", v))
+		}
+		return slog.Float64Value(f), nil
+	case string:
+		return slog.StringValue(v), nil
+	case bool:
+		return slog.BoolValue(v), nil
+	case nil:
+		return slog.AnyValue(nil), nil
+	}
+	return slog.Value{}, goerr.New("unexpected token", goerr.V("token", tok))
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/m-mizutani/clog/internal/decoded"
+	"github.com/m-mizutani/gt"
+)
+
+func TestDecodeRecord(t *testing.T) {
+	record, ok := decodeRecord([]byte(`{"time":"2024-01-02T03:04:05.678Z","level":"WARN+2","source":{"function":"main.main","file":"/src/main.go","line":12},"msg":"slow","req":{"path":"/api","ms":1.5},"n":3,"tags":["a",1],"ok":true,"none":null}` + "\n"))
+	gt.B(t, ok).True()
+
+	gt.V(t, record.Time).Equal(time.Date(2024, 1, 2, 3, 4, 5, 678000000, time.UTC))
+	gt.V(t, record.Level).Equal(slog.LevelWarn + 2)
+	gt.S(t, record.Message).Equal("slow")
+
+	var attrs []slog.Attr
+	record.Attrs(func(attr slog.Attr) bool {
+		attrs = append(attrs, attr)
+		return true
+	})
+	gt.A(t, attrs).Length(6)
+
+	gt.V(t, attrs[0].Value.Any().(*slog.Source)).Equal(&slog.Source{Function: "main.main", File: "/src/main.go", Line: 12})
+
+	gt.S(t, attrs[1].Key).Equal("req")
+	group := attrs[1].Value.Group()
+	gt.A(t, group).Length(2)
+	gt.S(t, group[0].Key).Equal("path")
+	gt.S(t, group[0].Value.String()).Equal("/api")
+	gt.S(t, group[1].Key).Equal("ms")
+	gt.N(t, group[1].Value.Float64()).Equal(1.5)
+
+	gt.N(t, attrs[2].Value.Int64()).Equal(3)
+	gt.V(t, attrs[3].Value.Any()).Equal([]any{"a", int64(1)})
+	gt.B(t, attrs[4].Value.Bool()).True()
+	gt.V(t, attrs[5].Value.Any()).Equal(nil)
+}
+
+func TestDecodeRecordNotLog(t *testing.T) {
+	for _, line := range []string{
+		"plain text",
+		`{"level":"INFO"}`,
+		`{"msg":"broken"`,
+		`{"msg":"x"} trailing`,
+		`["msg"]`,
+		"",
+	} {
+		_, ok := decodeRecord([]byte(line))
+		gt.B(t, ok).False()
+	}
+}
+
+func TestWithSource(t *testing.T) {
+	record, ok := decodeRecord([]byte(`{"msg":"slow","source":{"function":"main.main","file":"/src/main.go","line":12},"n":3}`))
+	gt.B(t, ok).True()
+
+	ctx, record := withSource(context.Background(), record)
+	gt.V(t, decoded.Source(ctx)).Equal(&slog.Source{Function: "main.main", File: "/src/main.go", Line: 12})
+	gt.N(t, record.NumAttrs()).Equal(1)
+
+	ctx, record = withSource(context.Background(), slog.NewRecord(time.Time{}, slog.LevelInfo, "no source", 0))
+	gt.V(t, decoded.Source(ctx)).Nil()
+	gt.S(t, record.Message).Equal("no source")
+}
@@ -0,0 +1,366 @@
+package main
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"log/slog"
+
+	"github.com/m-mizutani/clog"
+	"github.com/m-mizutani/goerr/v2"
+)
+
+// expr is a filter expression evaluated against a record. Grammar:
+//
+//	expr    := and ("||" and)*
+//	and     := unary ("&&" unary)*
+//	unary   := "!" unary | "(" expr ")" | compare
+//	compare := path [op literal]
+//	op      := "==" | "!=" | "<" | "<=" | ">" | ">=" | "~"
+//
+// path is a flattened attribute key such as "req.path", or "msg" and "level" of the record. A path without op is true if the attribute exists. A literal is a number, a quoted string, or a bare word. "~" matches a regular expression.
+type expr interface {
+	eval(rec *flatRecord) bool
+}
+
+// flatRecord is a record with attributes flattened by their group path like "req.path", as printed by clog.LinearPrinter.
+type flatRecord struct {
+	level slog.Level
+	msg   string
+	attrs map[string]slog.Value
+}
+
+func newFlatRecord(record slog.Record) *flatRecord {
+	rec := &flatRecord{
+		level: record.Level,
+		msg:   record.Message,
+		attrs: make(map[string]slog.Value, record.NumAttrs()),
+	}
+	record.Attrs(func(attr slog.Attr) bool {
+		rec.add(nil, attr)
+		return true
+	})
+	return rec
+}
+
+// add flattens the attribute in the same way as clog.Handler and clog.LinearPrinter: LogValuer is resolved, empty attributes are ignored and attributes of a group with an empty key are inlined.
+func (x *flatRecord) add(groups []string, attr slog.Attr) {
+	attr.Value = attr.Value.Resolve()
+	if attr.Equal(slog.Attr{}) {
+		return
+	}
+
+	if attr.Value.Kind() == slog.KindGroup {
+		if attr.Key != "" {
+			groups = append(groups[:len(groups):len(groups)], attr.Key)
+		}
+		for _, child := range attr.Value.Group() {
+			x.add(groups, child)
+		}
+		return
+	}
+	x.attrs[clog.LinearKey(groups, attr.Key)] = attr.Value
+}
+
+type orExpr struct{ left, right expr }
+
+func (x *orExpr) eval(rec *flatRecord) bool { return x.left.eval(rec) || x.right.eval(rec) }
+
+type andExpr struct{ left, right expr }
+
+func (x *andExpr) eval(rec *flatRecord) bool { return x.left.eval(rec) && x.right.eval(rec) }
+
+type notExpr struct{ expr expr }
+
+func (x *notExpr) eval(rec *flatRecord) bool { return !x.expr.eval(rec) }
+
+// compareExpr compares the value of path with the literal. It is false if the record has no value of path.
+type compareExpr struct {
+	path    string
+	op      string
+	literal string
+	re      *regexp.Regexp
+}
+
+func (x *compareExpr) eval(rec *flatRecord) bool {
+	var value string
+	var number float64
+	var isNumber bool
+
+	switch x.path {
+	case slog.MessageKey:
+		value = rec.msg
+	case slog.LevelKey:
+		var level slog.Level
+		if err := level.UnmarshalText([]byte(x.literal)); err == nil && x.op != "~" {
+			// levels are compared by their order, e.g. level>=warn
+			return compareNumber(x.op, float64(rec.level), float64(level))
+		}
+		value = rec.level.String()
+	default:
+		v, ok := rec.attrs[x.path]
+		if !ok {
+			return false
+		}
+		value = v.String()
+		switch v.Kind() {
+		case slog.KindInt64:
+			number, isNumber = float64(v.Int64()), true
+		case slog.KindUint64:
+			number, isNumber = float64(v.Uint64()), true
+		case slog.KindFloat64:
+			number, isNumber = v.Float64(), true
+		}
+	}
+
+	switch x.op {
+	case "":
+		return true
+	case "~":
+		return x.re.MatchString(value)
+	}
+
+	if !isNumber {
+		number, isNumber = parseNumber(value)
+	}
+	if literal, ok := parseNumber(x.literal); ok && isNumber {
+		return compareNumber(x.op, number, literal)
+	}
+
+	switch x.op {
+	case "==":
+		return value == x.literal
+	case "!=":
+		return value != x.literal
+	case "<":
+		return value < x.literal
+	case "<=":
+		return value <= x.literal
+	case ">":
+		return value > x.literal
+	case ">=":
+		return value >= x.literal
+	}
+	return false
+}
+
+func parseNumber(s string) (float64, bool) {
+	f, err := strconv.ParseFloat(s, 64)
+	return f, err == nil
+}
+
+func compareNumber(op string, a, b float64) bool {
+	switch op {
+	case "==":
+		return a == b
+	case "!=":
+		return a != b
+	case "<":
+		return a < b
+	case "<=":
+		return a <= b
+	case ">":
+		return a > b
+	case ">=":
+		return a >= b
+	}
+	return false
+}
+
+// parseExpr parses the filter expression.
+func parseExpr(src string) (expr, error) {
+	tokens, err := tokenize(src)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &exprParser{tokens: tokens}
+	e, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return nil, goerr.New("unexpected token", goerr.V("token", tok.text), goerr.V("expr", src))
+	}
+	return e, nil
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenWord
+	tokenString
+	tokenOp
+)
+
+type token struct {
+	kind tokenKind
+	text string
+}
+
+var operators = []string{"&&", "||", "==", "!=", "<=", ">=", "<", ">", "~", "!", "(", ")"}
+
+func tokenize(src string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case unicode.IsSpace(rune(c)):
+			i++
+
+		case c == '"':
+			end := i + 1
+			for end < len(src) && src[end] != '"' {
+				if src[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(src) {
+				return nil, goerr.New("unterminated string", goerr.V("expr", src))
+			}
+			s, err := strconv.Unquote(src[i : end+1])
+			if err != nil {
+				return nil, goerr.Wrap(err, "invalid string", goerr.V("string", src[i:end+1]))
+			}
+			tokens = append(tokens, token{kind: tokenString, text: s})
+			i = end + 1
+
+		case isWordChar(c):
+			end := i
+			for end < len(src) && isWordChar(src[end]) {
+				end++
+			}
+			tokens = append(tokens, token{kind: tokenWord, text: src[i:end]})
+			i = end
+
+		default:
+			found := false
+			for _, op := range operators {
+				if strings.HasPrefix(src[i:], op) {
+					tokens = append(tokens, token{kind: tokenOp, text: op})
+					i += len(op)
+					found = true
+					break
+				}
+			}
+			if !found {
+				return nil, goerr.New("unexpected character", goerr.V("char", string(c)), goerr.V("expr", src))
+			}
+		}
+	}
+	return append(tokens, token{kind: tokenEOF}), nil
+}
+
+func isWordChar(c byte) bool {
+	return c == '_' || c == '.' || c == '-' || c == '/' || c == ':' ||
+		('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9')
+}
+
+type exprParser struct {
+	tokens []token
+	pos    int
+}
+
+func (x *exprParser) peek() token {
+	return x.tokens[x.pos]
+}
+
+func (x *exprParser) next() token {
+	tok := x.tokens[x.pos]
+	if tok.kind != tokenEOF {
+		x.pos++
+	}
+	return tok
+}
+
+func (x *exprParser) parseOr() (expr, error) {
+	left, err := x.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for x.peek().kind == tokenOp && x.peek().text == "||" {
+		x.next()
+		right, err := x.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &orExpr{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (x *exprParser) parseAnd() (expr, error) {
+	left, err := x.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for x.peek().kind == tokenOp && x.peek().text == "&&" {
+		x.next()
+		right, err := x.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &andExpr{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (x *exprParser) parseUnary() (expr, error) {
+	tok := x.next()
+	switch {
+	case tok.kind == tokenOp && tok.text == "!":
+		e, err := x.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &notExpr{expr: e}, nil
+
+	case tok.kind == tokenOp && tok.text == "(":
+		e, err := x.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := x.next(); closing.kind != tokenOp || closing.text != ")" {
+			return nil, goerr.New("missing closing parenthesis")
+		}
+		return e, nil
+
+	case tok.kind == tokenWord:
+		return x.parseCompare(tok.text)
+	}
+	return nil, goerr.New("expected attribute path", goerr.V("token", tok.text))
+}
+
+func (x *exprParser) parseCompare(path string) (expr, error) {
+	e := &compareExpr{path: path}
+
+	op := x.peek()
+	if op.kind != tokenOp {
+		return e, nil
+	}
+	switch op.text {
+	case "==", "!=", "<", "<=", ">", ">=", "~":
+	default:
+		return e, nil
+	}
+	x.next()
+
+	literal := x.next()
+	if literal.kind != tokenWord && literal.kind != tokenString {
+		return nil, goerr.New("expected literal", goerr.V("op", op.text), goerr.V("token", literal.text))
+	}
+	e.op, e.literal = op.text, literal.text
+
+	if e.op == "~" {
+		re, err := regexp.Compile(e.literal)
+		if err != nil {
+			return nil, goerr.Wrap(err, "invalid regular expression", goerr.V("pattern", e.literal))
+		}
+		e.re = re
+	}
+	return e, nil
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/m-mizutani/gt"
+)
+
+func TestExpr(t *testing.T) {
+	record := slog.NewRecord(time.Now(), slog.LevelWarn, "slow request", 0)
+	record.AddAttrs(
+		slog.Int("status", 503),
+		slog.String("code", "042"),
+		slog.Group("req", slog.String("path", "/api/users"), slog.String("method", "GET")),
+		slog.Bool("cached", false),
+		slog.Group("", slog.Int("inlined", 1)),
+		slog.Group("empty"),
+	)
+	rec := newFlatRecord(record)
+
+	testCases := map[string]bool{
+		`status>=500`:                        true,
+		`status<500`:                         false,
+		`status==503 && req.path~"^/api/"`:   true,
+		`status==503 && req.path~"^/admin/"`: false,
+		`status==200 || req.method==GET`:     true,
+		`!(status==503)`:                     false,
+		`!status`:                            false,
+		`missing`:                            false,
+		`missing!=1`:                         false,
+		`code==42`:                           true,
+		`code=="042"`:                        true,
+		`cached==false`:                      true,
+		`level>=warn`:                        true,
+		`level>error`:                        false,
+		`level==WARN`:                        true,
+		`msg~slow`:                           true,
+		`msg=="slow request"`:                true,
+		`req.method==POST || (status>500 && !cached==true)`: true,
+		`inlined==1`: true,
+		`empty`:      false,
+	}
+	for src, expected := range testCases {
+		t.Run(src, func(t *testing.T) {
+			e := gt.R1(parseExpr(src)).NoError(t)
+			gt.V(t, e.eval(rec)).Equal(expected)
+		})
+	}
+}
+
+func TestExprInvalid(t *testing.T) {
+	for _, src := range []string{
+		`status>=`,
+		`(status>=500`,
+		`status>=500)`,
+		`path~"["`,
+		`"path"==1`,
+		`status @ 1`,
+		`path=="unterminated`,
+		``,
+	} {
+		_, err := parseExpr(src)
+		gt.Error(t, err)
+	}
+}
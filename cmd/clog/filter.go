@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"io"
+	"regexp"
+
+	"log/slog"
+
+	"github.com/m-mizutani/clog"
+	"github.com/m-mizutani/goerr/v2"
+)
+
+// filter selects records by the expression of --where and the pattern of --grep.
+type filter struct {
+	where expr
+	grep  *regexp.Regexp
+}
+
+func newFilter(where, grep string) (*filter, error) {
+	f := &filter{}
+	if where != "" {
+		e, err := parseExpr(where)
+		if err != nil {
+			return nil, goerr.Wrap(err, "invalid --where expression")
+		}
+		f.where = e
+	}
+	if grep != "" {
+		re, err := regexp.Compile(grep)
+		if err != nil {
+			return nil, goerr.Wrap(err, "invalid --grep pattern", goerr.V("pattern", grep))
+		}
+		f.grep = re
+	}
+	return f, nil
+}
+
+// matchRecord returns true if the message matches the grep pattern and the record satisfies the expression.
+func (x *filter) matchRecord(record slog.Record) bool {
+	if x.grep != nil && !x.grep.MatchString(record.Message) {
+		return false
+	}
+	return x.where == nil || x.where.eval(newFlatRecord(record))
+}
+
+// matchLine returns true if the line that is not a JSON log should be printed. Such lines never satisfy the expression.
+func (x *filter) matchLine(line []byte) bool {
+	if x.where != nil {
+		return false
+	}
+	return x.grep == nil || x.grep.Match(line)
+}
+
+// linePrinter prints JSON logs through clog.Handler and other lines as they are.
+type linePrinter struct {
+	decode  func(line []byte) (slog.Record, bool)
+	handler *clog.Handler
+	filter  *filter
+	w       io.Writer
+}
+
+// parsedLine is a line with the record decoded from it.
+type parsedLine struct {
+	raw    []byte
+	record slog.Record
+	ok     bool
+}
+
+// parse decodes the line. ok is false if the line is not a log record.
+func (x *linePrinter) parse(line []byte) parsedLine {
+	record, ok := x.decode(line)
+	return parsedLine{raw: line, record: record, ok: ok}
+}
+
+// match returns true if the line should be printed.
+func (x *linePrinter) match(ctx context.Context, line parsedLine) bool {
+	if line.ok {
+		return x.handler.Enabled(ctx, line.record.Level) && x.filter.matchRecord(line.record)
+	}
+	return x.filter.matchLine(line.raw)
+}
+
+// print prints the line if it matches filters.
+func (x *linePrinter) print(ctx context.Context, line []byte) error {
+	parsed := x.parse(line)
+	if !x.match(ctx, parsed) {
+		return nil
+	}
+	return x.write(ctx, parsed)
+}
+
+// write prints the line through clog.Handler if it is a record, or as it is otherwise.
+func (x *linePrinter) write(ctx context.Context, line parsedLine) error {
+	if line.ok {
+		ctx, record := withSource(ctx, line.record)
+		return x.handler.Handle(ctx, record)
+	}
+
+	raw := line.raw
+	if raw[len(raw)-1] != '\n' {
+		raw = append(raw, '\n')
+	}
+	if _, err := x.w.Write(raw); err != nil {
+		return goerr.Wrap(err, "failed to write line")
+	}
+	return nil
+}
@@ -0,0 +1,180 @@
+// Command clog pretty-prints JSON logs written by slog.JSONHandler through clog.Handler. It reads JSON lines from files or stdin, and lines that are not JSON logs are written as they are. Logfmt and clog's own output can be read with -format logfmt.
+//
+//	kubectl logs my-app | clog -printer pretty
+//	clog -level warn app.log
+//	clog -format logfmt -printer indent clog.log
+//	clog tail -f -where 'status>=500 && path~"/api"' app.log
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+
+	"github.com/m-mizutani/clog"
+	"github.com/m-mizutani/clog/internal/decoded"
+	"github.com/m-mizutani/goerr/v2"
+)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if err := run(ctx, os.Args[1:], os.Stdin, os.Stdout, os.Stderr); err != nil {
+		fmt.Fprintln(os.Stderr, "clog:", err)
+		os.Exit(1)
+	}
+}
+
+// run parses args and renders inputs to stdout.
+func run(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	if len(args) > 0 && args[0] == "tail" {
+		return runTail(ctx, args[1:], stdout, stderr)
+	}
+
+	fs := flag.NewFlagSet("clog", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: clog [options] [file ...]")
+		fmt.Fprintln(stderr, "       clog tail [-f] [options] file")
+		fmt.Fprintln(stderr, "Read logs from files or stdin and print them through clog. Use \"-\" for stdin.")
+		fs.PrintDefaults()
+	}
+	flags := addCommonFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	p, err := flags.linePrinter(stdout)
+	if err != nil {
+		return err
+	}
+
+	files := fs.Args()
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+
+	for _, file := range files {
+		if file == "-" {
+			if err := render(ctx, p, stdin); err != nil {
+				return err
+			}
+			continue
+		}
+
+		f, err := os.Open(file)
+		if err != nil {
+			return goerr.Wrap(err, "failed to open file", goerr.V("file", file))
+		}
+		err = render(ctx, p, f)
+		_ = f.Close()
+		if err != nil {
+			return goerr.Wrap(err, "failed to render file", goerr.V("file", file))
+		}
+	}
+	return nil
+}
+
+// commonFlags are flags of rendering and filtering shared by commands.
+type commonFlags struct {
+	tmpl    *string
+	printer *string
+	color   *string
+	level   *string
+	source  *bool
+	where   *string
+	grep    *string
+	format  *string
+	theme   *string
+}
+
+func addCommonFlags(fs *flag.FlagSet) *commonFlags {
+	return &commonFlags{
+		tmpl:    fs.String("template", "time", `template name ("standard", "time", "elapsed", "trace" or "delta") or Go template text`),
+		printer: fs.String("printer", "linear", `attribute printer ("linear", "pretty" or "indent")`),
+		theme:   fs.String("theme", "default", `color theme ("default" or "light")`),
+		color:   fs.String("color", "auto", `color output ("auto", "always" or "never")`),
+		level:   fs.String("level", "debug", "minimum level of records to print"),
+		source:  fs.Bool("source", true, "print source location if the record has it"),
+		where:   fs.String("where", "", `print only records matching the expression, e.g. 'status>=500 && path~"/api"'`),
+		grep:    fs.String("grep", "", "print only records whose message matches the regular expression"),
+		format:  fs.String("format", "json", `input format ("json", "logfmt" or "auto"). "logfmt" also reads clog's own output`),
+	}
+}
+
+// linePrinter builds a linePrinter writing to w from flag values.
+func (x *commonFlags) linePrinter(w io.Writer) (*linePrinter, error) {
+	opts, err := x.options()
+	if err != nil {
+		return nil, err
+	}
+	h, err := clog.NewFromOptions(opts, clog.WithWriter(w), decoded.SourceOption.(clog.Option))
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := newFilter(*x.where, *x.grep)
+	if err != nil {
+		return nil, err
+	}
+
+	decode, ok := decoders[*x.format]
+	if !ok {
+		return nil, goerr.New("unknown format", goerr.V("format", *x.format))
+	}
+
+	return &linePrinter{
+		decode:  decode,
+		handler: h,
+		filter:  f,
+		w:       w,
+	}, nil
+}
+
+// options converts flag values to Options of clog.
+func (x *commonFlags) options() (clog.Options, error) {
+	opts := clog.Options{
+		Level:    *x.level,
+		Source:   x.source,
+		Template: *x.tmpl,
+		Printer:  *x.printer,
+		Theme:    *x.theme,
+	}
+
+	switch *x.color {
+	case "auto":
+	case "always", "never":
+		enabled := *x.color == "always"
+		opts.Color = &enabled
+	default:
+		return clog.Options{}, goerr.New("unknown color mode", goerr.V("color", *x.color))
+	}
+
+	return opts, nil
+}
+
+// render reads lines from r and prints them by p.
+func render(ctx context.Context, p *linePrinter, r io.Reader) error {
+	br := bufio.NewReader(r)
+	for {
+		line, err := br.ReadBytes('\n')
+		if len(line) > 0 {
+			if err := p.print(ctx, line); err != nil {
+				return err
+			}
+		}
+
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return goerr.Wrap(err, "failed to read line")
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/m-mizutani/gt"
+)
+
+const testInput = `{"time":"2024-01-02T03:04:05.678Z","level":"INFO","msg":"started","port":8080}
+panic: something wrong
+{"time":"2024-01-02T03:04:06Z","level":"DEBUG","msg":"tick"}
+{"time":"2024-01-02T03:04:07Z","level":"ERROR","source":{"function":"main.run","file":"/src/main.go","line":42},"msg":"failed","req":{"path":"/api"}}`
+
+func TestRun(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	err := run(context.Background(), []string{"-color", "never", "-template", "standard"}, strings.NewReader(testInput), &stdout, &stderr)
+	gt.NoError(t, err)
+
+	gt.S(t, stdout.String()).Equal(strings.Join([]string{
+		`INFO started port=8080 `,
+		`panic: something wrong`,
+		`DEBUG tick `,
+		`ERROR [main.go:42] failed req.path="/api" `,
+		``,
+	}, "\n"))
+}
+
+func TestRunLevelAndFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	gt.NoError(t, os.WriteFile(path, []byte(testInput), 0600))
+
+	var stdout, stderr bytes.Buffer
+	err := run(context.Background(), []string{"-color", "never", "-level", "info", "-source=false", "-template", "{{.Level}}: {{.Message}} ", path}, nil, &stdout, &stderr)
+	gt.NoError(t, err)
+
+	gt.S(t, stdout.String()).Equal(strings.Join([]string{
+		`INFO: started port=8080 `,
+		`panic: something wrong`,
+		`ERROR: failed req.path="/api" `,
+		``,
+	}, "\n"))
+}
+
+func TestRunInvalidFlags(t *testing.T) {
+	for _, args := range [][]string{
+		{"-printer", "unknown"},
+		{"-color", "sometimes"},
+		{"-level", "verbose"},
+		{"-template", "unknown"},
+		{"-format", "xml"},
+	} {
+		var stdout, stderr bytes.Buffer
+		gt.Error(t, run(context.Background(), args, strings.NewReader(""), &stdout, &stderr))
+	}
+}
+
+func TestRunLogfmt(t *testing.T) {
+	input := strings.Join([]string{
+		`time=2024-01-02T03:04:05Z level=WARN msg="slow request" req.path=/api req.ms=12`,
+		`03:04:06.000 ERROR [main.go:42] failed err="timeout" req.path="/api"`,
+		`panic: something wrong`,
+	}, "\n")
+
+	var stdout, stderr bytes.Buffer
+	err := run(context.Background(), []string{"-color", "never", "-template", "standard", "-format", "logfmt", "-where", "req.path==/api"}, strings.NewReader(input), &stdout, &stderr)
+	gt.NoError(t, err)
+
+	gt.S(t, stdout.String()).Equal(strings.Join([]string{
+		`WARN slow request req.path="/api" req.ms=12 `,
+		`ERROR [main.go:42] failed err="timeout" req.path="/api" `,
+		``,
+	}, "\n"))
+}
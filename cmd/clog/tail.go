@@ -0,0 +1,249 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/m-mizutani/goerr/v2"
+)
+
+// runTail prints the last lines of the file, and keeps printing appended lines if -f is given.
+func runTail(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("clog tail", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: clog tail [-f] [options] file")
+		fmt.Fprintln(stderr, "Print the last records of the file that match filters.")
+		fs.PrintDefaults()
+	}
+	var (
+		follow   = fs.Bool("f", false, "keep printing records appended to the file. Rotation and truncation of the file are followed")
+		lines    = fs.Int("n", 10, "number of last matched lines to print first. Negative value prints all lines")
+		interval = fs.Duration("interval", 250*time.Millisecond, "polling interval of -f")
+	)
+	flags := addCommonFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return goerr.New("tail requires exactly one file")
+	}
+	path := fs.Arg(0)
+
+	p, err := flags.linePrinter(stdout)
+	if err != nil {
+		return err
+	}
+
+	t := &tailer{path: path, printer: p}
+	if err := t.open(); err != nil {
+		return err
+	}
+	defer t.close()
+
+	if err := t.printLast(ctx, *lines); err != nil {
+		return err
+	}
+	if !*follow {
+		return nil
+	}
+	return t.follow(ctx, *interval)
+}
+
+// tailer reads lines appended to the file.
+type tailer struct {
+	path    string
+	printer *linePrinter
+
+	file    *os.File
+	reader  *bufio.Reader
+	offset  int64
+	pending []byte
+}
+
+func (x *tailer) open() error {
+	f, err := os.Open(x.path)
+	if err != nil {
+		return goerr.Wrap(err, "failed to open file", goerr.V("file", x.path))
+	}
+	x.file, x.reader, x.offset, x.pending = f, bufio.NewReader(f), 0, nil
+	return nil
+}
+
+func (x *tailer) close() {
+	if x.file != nil {
+		_ = x.file.Close()
+	}
+}
+
+// readLines reads complete lines until the end of the file. An incomplete last line is kept until its newline is written.
+func (x *tailer) readLines(fn func(line []byte) error) error {
+	for {
+		data, err := x.reader.ReadBytes('\n')
+		x.offset += int64(len(data))
+		x.pending = append(x.pending, data...)
+
+		if err == nil {
+			line := x.pending
+			x.pending = nil
+			if err := fn(line); err != nil {
+				return err
+			}
+			continue
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		return goerr.Wrap(err, "failed to read file", goerr.V("file", x.path))
+	}
+}
+
+// printLast prints the last n lines that match filters. The file is read backwards from the end, so that only lines to be checked are read and decoded.
+func (x *tailer) printLast(ctx context.Context, n int) error {
+	if n < 0 {
+		return x.readLines(func(line []byte) error {
+			return x.printer.print(ctx, line)
+		})
+	}
+
+	var matched []parsedLine
+	end, err := x.scanBackward(func(line []byte) bool {
+		if len(matched) >= n {
+			return false
+		}
+		if parsed := x.printer.parse(line); x.printer.match(ctx, parsed) {
+			matched = append(matched, parsed)
+		}
+		return len(matched) < n
+	})
+	if err != nil {
+		return err
+	}
+
+	// appended lines are read from the end of the last complete line
+	if _, err := x.file.Seek(end, io.SeekStart); err != nil {
+		return goerr.Wrap(err, "failed to seek file", goerr.V("file", x.path))
+	}
+	x.reader.Reset(x.file)
+	x.offset, x.pending = end, nil
+
+	for i := len(matched) - 1; i >= 0; i-- {
+		if err := x.printer.write(ctx, matched[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// scanChunkSize is the size of chunks read by scanBackward.
+const scanChunkSize = 64 * 1024
+
+// scanBackward calls fn for complete lines from the end of the file until fn returns false. It returns the offset of the end of the last complete line. An incomplete last line is left to be read after its newline is written.
+func (x *tailer) scanBackward(fn func(line []byte) bool) (int64, error) {
+	stat, err := x.file.Stat()
+	if err != nil {
+		return 0, goerr.Wrap(err, "failed to stat file", goerr.V("file", x.path))
+	}
+
+	pos, end := stat.Size(), int64(-1)
+	// rest is data from pos that is not passed to fn yet. It ends with a newline once end is found.
+	var rest []byte
+	for {
+		if end < 0 {
+			if i := bytes.LastIndexByte(rest, '\n'); i >= 0 {
+				end = pos + int64(i) + 1
+				rest = rest[:i+1]
+			}
+		}
+		if end >= 0 {
+			for len(rest) > 0 {
+				i := bytes.LastIndexByte(rest[:len(rest)-1], '\n')
+				if i < 0 && pos > 0 {
+					// the first line may continue before pos
+					break
+				}
+				if !fn(rest[i+1:]) {
+					return end, nil
+				}
+				rest = rest[:i+1]
+			}
+		}
+		if pos == 0 {
+			return max(end, 0), nil
+		}
+
+		size := min(scanChunkSize, pos)
+		pos -= size
+		chunk := make([]byte, size, size+int64(len(rest)))
+		if _, err := x.file.ReadAt(chunk, pos); err != nil {
+			return 0, goerr.Wrap(err, "failed to read file", goerr.V("file", x.path))
+		}
+		rest = append(chunk, rest...)
+	}
+}
+
+// follow polls the file and prints appended lines until ctx is canceled.
+func (x *tailer) follow(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := x.readLines(func(line []byte) error {
+			return x.printer.print(ctx, line)
+		}); err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		if err := x.checkRotation(ctx); err != nil {
+			return err
+		}
+	}
+}
+
+// checkRotation reopens the file if it is replaced, and reads it again from the beginning if it is truncated.
+func (x *tailer) checkRotation(ctx context.Context) error {
+	stat, err := os.Stat(x.path)
+	if err != nil {
+		// the file may be moved and not created yet
+		return nil
+	}
+
+	current, err := x.file.Stat()
+	if err != nil {
+		return goerr.Wrap(err, "failed to stat file", goerr.V("file", x.path))
+	}
+
+	if !os.SameFile(stat, current) {
+		// lines written to the old file before rotation are printed first
+		if err := x.readLines(func(line []byte) error {
+			return x.printer.print(ctx, line)
+		}); err != nil {
+			return err
+		}
+		x.close()
+		return x.open()
+	}
+
+	if stat.Size() < x.offset {
+		if _, err := x.file.Seek(0, io.SeekStart); err != nil {
+			return goerr.Wrap(err, "failed to seek file", goerr.V("file", x.path))
+		}
+		x.reader.Reset(x.file)
+		x.offset, x.pending = 0, nil
+	}
+	return nil
+}
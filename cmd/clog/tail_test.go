@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/m-mizutani/gt"
+)
+
+// syncBuffer is a bytes.Buffer that can be read while written by another goroutine.
+type syncBuffer struct {
+	mutex sync.Mutex
+	buf   bytes.Buffer
+}
+
+func (x *syncBuffer) Write(p []byte) (int, error) {
+	x.mutex.Lock()
+	defer x.mutex.Unlock()
+	return x.buf.Write(p)
+}
+
+func (x *syncBuffer) String() string {
+	x.mutex.Lock()
+	defer x.mutex.Unlock()
+	return x.buf.String()
+}
+
+const tailInput = `{"level":"INFO","msg":"request","status":200,"path":"/api/users"}
+{"level":"ERROR","msg":"request","status":503,"path":"/api/users"}
+not json
+{"level":"WARN","msg":"slow","status":200,"path":"/health"}
+{"level":"ERROR","msg":"request","status":500,"path":"/admin"}
+`
+
+func TestTailFilter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	gt.NoError(t, os.WriteFile(path, []byte(tailInput), 0600))
+
+	testCases := map[string]struct {
+		args     []string
+		expected []string
+	}{
+		"last lines": {
+			args:     []string{"-n", "2"},
+			expected: []string{`WARN slow status=200 path="/health" `, `ERROR request status=500 path="/admin" `},
+		},
+		"level": {
+			args:     []string{"--level", "warn"},
+			expected: []string{`ERROR request status=503 path="/api/users" `, `not json`, `WARN slow status=200 path="/health" `, `ERROR request status=500 path="/admin" `},
+		},
+		"where": {
+			args:     []string{"--where", `status>=500 && path~"/api"`},
+			expected: []string{`ERROR request status=503 path="/api/users" `},
+		},
+		"grep": {
+			args:     []string{"--grep", "^slo"},
+			expected: []string{`WARN slow status=200 path="/health" `},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			args := append([]string{"tail", "-color", "never", "-template", "standard"}, tc.args...)
+			gt.NoError(t, run(context.Background(), append(args, path), nil, &stdout, &stderr))
+			gt.S(t, stdout.String()).Equal(strings.Join(tc.expected, "\n") + "\n")
+		})
+	}
+}
+
+func TestTailLargeFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	var data strings.Builder
+	for i := 0; i < 5000; i++ {
+		fmt.Fprintf(&data, `{"level":"INFO","msg":"line","i":%d}`+"\n", i)
+	}
+	// the incomplete last line is not printed
+	data.WriteString(`{"level":"INFO","msg":"partial"`)
+	gt.NoError(t, os.WriteFile(path, []byte(data.String()), 0600))
+
+	testCases := map[string]struct {
+		args     []string
+		expected string
+	}{
+		"last lines":       {args: []string{"-n", "2"}, expected: "INFO line i=4998 \nINFO line i=4999 \n"},
+		"across chunks":    {args: []string{"-n", "2", "--where", "i<2"}, expected: "INFO line i=0 \nINFO line i=1 \n"},
+		"no lines":         {args: []string{"-n", "0"}, expected: ""},
+		"fewer than lines": {args: []string{"-n", "3", "--where", "i==10"}, expected: "INFO line i=10 \n"},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			args := append([]string{"tail", "-color", "never", "-template", "standard"}, tc.args...)
+			gt.NoError(t, run(context.Background(), append(args, path), nil, &stdout, &stderr))
+			gt.S(t, stdout.String()).Equal(tc.expected)
+		})
+	}
+}
+
+func TestTailFollow(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	gt.NoError(t, os.WriteFile(path, []byte(tailInput), 0600))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var stdout, stderr syncBuffer
+	done := make(chan error, 1)
+	go func() {
+		done <- run(ctx, []string{"tail", "-f", "-n", "0", "-interval", "10ms", "-color", "never", "-template", "standard", "--level", "error", path}, nil, &stdout, &stderr)
+	}()
+
+	waitFor := func(expected string) {
+		t.Helper()
+		for i := 0; i < 200 && !strings.Contains(stdout.String(), expected); i++ {
+			time.Sleep(10 * time.Millisecond)
+		}
+		gt.S(t, stdout.String()).Contains(expected)
+	}
+
+	appendFile := func(data string) {
+		f := gt.R1(os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0600)).NoError(t)
+		gt.R1(f.WriteString(data)).NoError(t)
+		gt.NoError(t, f.Close())
+	}
+
+	// a line is printed after its newline is written
+	appendFile(`{"level":"ERROR","msg":"appended",`)
+	time.Sleep(50 * time.Millisecond)
+	appendFile(`"n":1}` + "\n" + `{"level":"INFO","msg":"ignored"}` + "\n")
+	waitFor("ERROR appended n=1 \n")
+
+	// rotation
+	gt.NoError(t, os.Rename(path, filepath.Join(dir, "app.log.1")))
+	gt.NoError(t, os.WriteFile(path, []byte(`{"level":"ERROR","msg":"rotated"}`+"\n"), 0600))
+	waitFor("ERROR rotated \n")
+
+	cancel()
+	gt.NoError(t, <-done)
+	gt.S(t, stdout.String()).Equal("ERROR appended n=1 \nERROR rotated \n")
+}
@@ -1,6 +1,7 @@
 package clog
 
 import (
+	"hash/fnv"
 	"os"
 	"strings"
 
@@ -18,13 +19,43 @@ type ColorMap struct {
 	// Whether AttrKey and AttrValue color settings are used or not depends on the AttrPrinter
 	AttrKey   *color.Color
 	AttrValue *color.Color
+
+	// Palette is a set of colors that are assigned to values by hash, e.g. trace IDs. The default palette is used if empty.
+	Palette []*color.Color
 }
 
 var (
 	defaultColorMap    *ColorMap
 	enableColorDefault = false
+
+	defaultPalette = []*color.Color{
+		color.New(color.FgRed),
+		color.New(color.FgGreen),
+		color.New(color.FgYellow),
+		color.New(color.FgBlue),
+		color.New(color.FgMagenta),
+		color.New(color.FgCyan),
+		color.New(color.FgHiRed),
+		color.New(color.FgHiGreen),
+		color.New(color.FgHiYellow),
+		color.New(color.FgHiBlue),
+		color.New(color.FgHiMagenta),
+		color.New(color.FgHiCyan),
+	}
 )
 
+// hashColor returns a color picked from the palette by hash of s. The same value always gets the same color.
+func (x *ColorMap) hashColor(s string) *color.Color {
+	palette := x.Palette
+	if len(palette) == 0 {
+		palette = defaultPalette
+	}
+
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(s))
+	return palette[h.Sum32()%uint32(len(palette))]
+}
+
 func init() {
 	defaultColorMap = &ColorMap{
 		Level: map[slog.Level]*color.Color{
@@ -39,6 +70,8 @@ func init() {
 
 		AttrKey:   color.New(color.FgWhite),
 		AttrValue: color.New(color.FgHiWhite),
+
+		Palette: defaultPalette,
 	}
 
 	colorTerminals := []string{
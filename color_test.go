@@ -0,0 +1,91 @@
+package clog_test
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/m-mizutani/clog"
+	"github.com/m-mizutani/gt"
+)
+
+func enableColorOutput(t *testing.T) {
+	noColor := color.NoColor
+	color.NoColor = false
+	t.Cleanup(func() { color.NoColor = noColor })
+}
+
+func TestHashColorKeys(t *testing.T) {
+	enableColorOutput(t)
+
+	red := color.New(color.FgRed)
+	blue := color.New(color.FgBlue)
+	colors := &clog.ColorMap{
+		Palette: []*color.Color{red, blue},
+	}
+
+	printers := map[string]clog.Option{
+		"linear": clog.WithPrinter(clog.LinearPrinter),
+		"pretty": clog.WithPrinter(clog.PrettyPrinter),
+		"indent": clog.WithPrinter(clog.IndentPrinter),
+	}
+
+	for name, printer := range printers {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger := slog.New(clog.New(
+				clog.WithWriter(&buf),
+				clog.WithColor(true),
+				clog.WithColorMap(colors),
+				clog.WithHashColorKeys("request_id", "job.worker"),
+				printer,
+			))
+
+			colored := func(s string) string {
+				for _, c := range []*color.Color{red, blue} {
+					if out := c.Sprint(s); strings.Contains(buf.String(), out) {
+						return out
+					}
+				}
+				return ""
+			}
+
+			logger.Info("first", slog.String("request_id", "req-1"), slog.Group("job", slog.Int("worker", 3)))
+			first := colored(`"req-1"`) + colored("req-1")
+			gt.S(t, first).NotEqual("")
+			gt.S(t, colored("3")).NotEqual("")
+
+			buf.Reset()
+			logger.Info("second", slog.String("request_id", "req-1"))
+			gt.S(t, colored(`"req-1"`)+colored("req-1")).Equal(first)
+		})
+	}
+}
+
+func TestHeaderHashColor(t *testing.T) {
+	enableColorOutput(t)
+
+	red := color.New(color.FgRed)
+	colors := &clog.ColorMap{
+		Palette: []*color.Color{red},
+	}
+
+	var buf bytes.Buffer
+	logger := slog.New(clog.New(
+		clog.WithWriter(&buf),
+		clog.WithColor(true),
+		clog.WithColorMap(colors),
+		clog.WithHeaderHashColor("worker"),
+	))
+
+	logger.Info("with worker", slog.Int("worker", 1))
+	gt.S(t, buf.String()).Contains(red.Sprint("with worker"))
+
+	buf.Reset()
+	logger.Info("without worker")
+	gt.S(t, buf.String()).
+		Contains("without worker").
+		NotContains(red.Sprint("without worker"))
+}
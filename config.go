@@ -2,26 +2,68 @@ package clog
 
 import (
 	"bytes"
+	"context"
 	"io"
 	"os"
+	"strings"
 	"text/template"
+	"time"
 
 	"log/slog"
 )
 
-// config is the configuration for the handler. The struct is immutable after creation.
+// matchHashKey returns true if the attribute matches the key by the attribute key or the full path.
+func matchHashKey(keys map[string]struct{}, groups []string, attrKey string) bool {
+	if len(keys) == 0 {
+		return false
+	}
+	if _, ok := keys[attrKey]; ok {
+		return true
+	}
+	if len(groups) == 0 {
+		return false
+	}
+	_, ok := keys[strings.Join(groups, ".")+"."+attrKey]
+	return ok
+}
+
+// config is the configuration for the handler. The struct is immutable after creation. Watch replaces the whole struct instead of modifying it.
 type config struct {
-	w              io.Writer
-	level          slog.Leveler
-	timeFmt        string
-	addSource      bool
+	w         io.Writer
+	level     slog.Leveler
+	timeFmt   string
+	addSource bool
+	// decodedSource enables the source of records decoded by cmd/clog
+	decodedSource  bool
 	enableColor    bool
 	replaceAttr    func(groups []string, a slog.Attr) slog.Attr
-	newAttrPrinter func(io.Writer, *config) AttrPrinter
+	newAttrPrinter func(io.Writer, PrinterContext) AttrPrinter
 	colors         *ColorMap
 	tmpl           *template.Template
 	attrHooks      []AttrHook
 	levelFormatter func(slog.Level) string
+	ctxExtractors  []func(ctx context.Context) []slog.Attr
+	promotedKeys   map[string]struct{}
+	promotedOrder  []string
+	traceContext   func(ctx context.Context) (traceID, spanID string)
+	hashColorKeys  map[string]struct{}
+	headerHashKeys map[string]struct{}
+	colorSpecified bool
+	levelWriters   []destination
+	defaultDest    destination
+	ring           *Ring
+	clock          Clock
+	clockSpecified bool
+	startTime      time.Time
+	elapsedSince   time.Time
+	delta          *deltaTracker
+	sampler        *Sampler
+	dedupEnabled   bool
+	dedupTimeout   time.Duration
+	dedupRewrite   bool
+	dedup          *deduplicator
+	statusLine     bool
+	status         *statusState
 }
 
 func newConfig() *config {
@@ -36,12 +78,46 @@ func newConfig() *config {
 		colors:         defaultColorMap,
 		tmpl:           defaultTmpl,
 		levelFormatter: DefaultLevelFormatter,
+		clock:          systemClock{},
+	}
+}
+
+// finalize completes the config after all options are applied. Destinations are rebuilt every time because color of each writer depends on options. States shared by handlers derived from the same handler, i.e. the start time of Elapsed, the deduplicator and the status line, are created only once, so that a copy of the config reloaded by Watch keeps them.
+func (x *config) finalize() {
+	x.defaultDest = x.newDestination(x.w)
+	for i := range x.levelWriters {
+		dst := x.newDestination(x.levelWriters[i].w)
+		x.levelWriters[i].color = dst.color
+		x.levelWriters[i].terminal = dst.terminal
+	}
+
+	if x.delta == nil {
+		switch {
+		case !x.elapsedSince.IsZero():
+			x.startTime = x.elapsedSince
+		case x.clock == Clock(systemClock{}):
+			x.startTime = processStart
+		default:
+			x.startTime = x.clock.Now()
+		}
+		x.delta = &deltaTracker{}
+	}
+	if x.dedup == nil && x.dedupEnabled {
+		x.dedup = &deduplicator{
+			timeout: x.dedupTimeout,
+			rewrite: x.dedupRewrite,
+		}
+	}
+	if x.status == nil && x.statusLine {
+		x.status = &statusState{}
 	}
 }
 
 const (
 	TemplateStandardWithElapsed = `{{.Elapsed | printf "%8.3f" }} {{.Level}} {{ if .FileName }}[{{.FileName}}:{{.FileLine}}] {{ end }}{{.Message}} `
 	TemplateStandardWithTime    = `{{.Timestamp}} {{.Level}} {{ if .FileName }}[{{.FileName}}:{{.FileLine}}] {{ end }}{{.Message}} `
+	TemplateStandardWithTrace   = `{{.Timestamp}} {{.Level}} {{ if .TraceID }}[{{.ShortTraceID}}] {{ end }}{{ if .FileName }}[{{.FileName}}:{{.FileLine}}] {{ end }}{{.Message}} `
+	TemplateStandardWithDelta   = `{{.Delta | printf "+%7.3f" }} {{.Level}} {{ if .FileName }}[{{.FileName}}:{{.FileLine}}] {{ end }}{{.Message}} `
 	TemplateStandard            = `{{.Level}} {{ if .FileName }}[{{.FileName}}:{{.FileLine}}] {{ end }}{{.Message}} `
 	DefaultTemplate             = TemplateStandardWithTime
 )
@@ -79,10 +155,11 @@ func WithTimeFmt(timeFmt string) Option {
 	}
 }
 
-// WithColor enables or disables color output. The default is enabled.
+// WithColor enables or disables color output for all writers. By default, color is enabled if the terminal supports color and the writer is a terminal.
 func WithColor(color bool) Option {
 	return func(cfg *config) {
 		cfg.enableColor = color
+		cfg.colorSpecified = true
 	}
 }
 
@@ -108,7 +185,7 @@ func WithReplaceAttr(replaceAttr func(groups []string, a slog.Attr) slog.Attr) O
 }
 
 // WithPrinter sets the printer for printing attributes. The default is LinearPrinter.
-func WithPrinter(printer func(io.Writer, *config) AttrPrinter) Option {
+func WithPrinter(printer func(io.Writer, PrinterContext) AttrPrinter) Option {
 	return func(cfg *config) {
 		cfg.newAttrPrinter = printer
 	}
@@ -117,19 +194,7 @@ func WithPrinter(printer func(io.Writer, *config) AttrPrinter) Option {
 // WithTemplate sets the template for the handler. The default is DefaultTemplate. This option executes dry run and panics if the template is invalid.
 func WithTemplate(tmpl *template.Template) Option {
 	return func(cfg *config) {
-		// dry run
-		log := &Log{
-			Timestamp: "2006-01-02 15:04:05",
-			Elapsed:   1.23456789,
-			Level:     "INFO",
-			Message:   "hello, world!",
-			FileName:  "foo.go",
-			FilePath:  "/path/to/foo.go",
-			FuncName:  "main",
-			FileLine:  10,
-		}
-		var buf bytes.Buffer
-		if err := tmpl.Execute(&buf, log); err != nil {
+		if err := dryRunTemplate(tmpl); err != nil {
 			panic(err)
 		}
 
@@ -137,6 +202,27 @@ func WithTemplate(tmpl *template.Template) Option {
 	}
 }
 
+// dryRunTemplate executes the template with sample data to check if it is valid.
+func dryRunTemplate(tmpl *template.Template) error {
+	log := &Log{
+		Timestamp: "2006-01-02 15:04:05",
+		Elapsed:   1.23456789,
+		Delta:     0.123456789,
+		Level:     "INFO",
+		Message:   "hello, world!",
+		FileName:  "foo.go",
+		FilePath:  "/path/to/foo.go",
+		FuncName:  "main",
+		FileLine:  10,
+
+		TraceID:      "4bf92f3577b34da6a3ce929d0e0e4736",
+		ShortTraceID: "4bf92f35",
+		SpanID:       "00f067aa0ba902b7",
+	}
+	var buf bytes.Buffer
+	return tmpl.Execute(&buf, log)
+}
+
 // WithAttrHook adds an attribute hook to the handler. This option can be used with only LinearPrinter.
 func WithAttrHook(hook AttrHook) Option {
 	return func(cfg *config) {
@@ -160,3 +246,51 @@ func WithLevelFormatter(f func(slog.Level) string) Option {
 		}
 	}
 }
+
+// WithContextExtractor adds a function that extracts attributes from the context given to Handle. The extracted attributes are printed as if they had been added with WithAttrs, before any other attributes. AttrsFromContext can be used as the extractor for attributes stored by ContextWith.
+func WithContextExtractor(extractor func(ctx context.Context) []slog.Attr) Option {
+	return func(cfg *config) {
+		cfg.ctxExtractors = append(cfg.ctxExtractors, extractor)
+	}
+}
+
+// WithPromotedAttrs promotes top-level attributes with the given keys into the template. A promoted attribute is not printed by AttrPrinter, and its value is available as `{{.Attrs.key}}` in the template instead.
+func WithPromotedAttrs(keys ...string) Option {
+	return func(cfg *config) {
+		if cfg.promotedKeys == nil {
+			cfg.promotedKeys = make(map[string]struct{}, len(keys))
+		}
+		for _, key := range keys {
+			if _, ok := cfg.promotedKeys[key]; !ok {
+				cfg.promotedOrder = append(cfg.promotedOrder, key)
+			}
+			cfg.promotedKeys[key] = struct{}{}
+		}
+	}
+}
+
+// WithTraceContext sets the function that extracts trace and span IDs from the context given to Handle. The IDs are available as `.TraceID`, `.ShortTraceID` and `.SpanID` in the template. See the otel subpackage for OpenTelemetry.
+func WithTraceContext(f func(ctx context.Context) (traceID, spanID string)) Option {
+	return func(cfg *config) {
+		cfg.traceContext = f
+	}
+}
+
+// WithHashColorKeys sets attribute keys whose values are colored by hash. A value always gets the same color from ColorMap.Palette, so correlated records such as the same request_id are easily found. A key matches either the attribute key or the full path joined by "." (e.g. "req.id"). This option works only when color is enabled.
+func WithHashColorKeys(keys ...string) Option {
+	return func(cfg *config) {
+		if cfg.hashColorKeys == nil {
+			cfg.hashColorKeys = make(map[string]struct{}, len(keys))
+		}
+		for _, key := range keys {
+			cfg.hashColorKeys[key] = struct{}{}
+		}
+	}
+}
+
+// WithHeaderHashColor colors the header line (timestamp, message and source) by hash of the value of the attribute with the key. The level keeps the level color. The key matches in the same way as WithHashColorKeys. This option works only when color is enabled.
+func WithHeaderHashColor(key string) Option {
+	return func(cfg *config) {
+		cfg.headerHashKeys = map[string]struct{}{key: {}}
+	}
+}
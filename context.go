@@ -0,0 +1,27 @@
+package clog
+
+import (
+	"context"
+
+	"log/slog"
+)
+
+type ctxAttrsKey struct{}
+
+// ContextWith returns a copy of ctx that carries attrs. Attributes already stored in ctx by ContextWith are kept and attrs are appended to them. The attributes can be retrieved by AttrsFromContext.
+func ContextWith(ctx context.Context, attrs ...slog.Attr) context.Context {
+	current := AttrsFromContext(ctx)
+	merged := make([]slog.Attr, 0, len(current)+len(attrs))
+	merged = append(merged, current...)
+	merged = append(merged, attrs...)
+	return context.WithValue(ctx, ctxAttrsKey{}, merged)
+}
+
+// AttrsFromContext returns attributes stored in ctx by ContextWith. It can be used as an extractor of WithContextExtractor.
+func AttrsFromContext(ctx context.Context) []slog.Attr {
+	if ctx == nil {
+		return nil
+	}
+	attrs, _ := ctx.Value(ctxAttrsKey{}).([]slog.Attr)
+	return attrs
+}
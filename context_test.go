@@ -0,0 +1,79 @@
+package clog_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+	"text/template"
+
+	"github.com/m-mizutani/clog"
+	"github.com/m-mizutani/gt"
+)
+
+func TestContextExtractor(t *testing.T) {
+	w := &bytes.Buffer{}
+	logger := slog.New(clog.New(
+		clog.WithColor(false),
+		clog.WithWriter(w),
+		clog.WithContextExtractor(clog.AttrsFromContext),
+	))
+
+	ctx := clog.ContextWith(context.Background(), slog.String("request_id", "req-1"))
+	ctx = clog.ContextWith(ctx, slog.String("user_id", "alice"))
+	logger.WithGroup("g").InfoContext(ctx, "hello, world!", slog.String("foo", "bar"))
+
+	gt.String(t, w.String()).
+		Contains(`request_id="req-1" user_id="alice" g.foo="bar"`).
+		NotContains("g.request_id")
+
+	w.Reset()
+	logger.Info("no context attrs", slog.String("foo", "bar"))
+	gt.String(t, w.String()).
+		Contains(`foo="bar"`).
+		NotContains("request_id")
+}
+
+func TestAttrsFromContext(t *testing.T) {
+	ctx := clog.ContextWith(context.Background(), slog.String("a", "1"))
+	child := clog.ContextWith(ctx, slog.String("b", "2"))
+
+	gt.A(t, clog.AttrsFromContext(ctx)).Length(1)
+	gt.A(t, clog.AttrsFromContext(child)).Length(2)
+	gt.A(t, clog.AttrsFromContext(context.Background())).Length(0)
+}
+
+func TestPromotedAttrs(t *testing.T) {
+	w := &bytes.Buffer{}
+	tmpl := template.Must(template.New("log").Parse(`[{{.Attrs.request_id}}] {{.Level}} {{.Message}} `))
+	logger := slog.New(clog.New(
+		clog.WithColor(false),
+		clog.WithWriter(w),
+		clog.WithTemplate(tmpl),
+		clog.WithContextExtractor(clog.AttrsFromContext),
+		clog.WithPromotedAttrs("request_id"),
+	))
+
+	ctx := clog.ContextWith(context.Background(), slog.String("request_id", "req-1"))
+	logger.InfoContext(ctx, "hello, world!", slog.String("foo", "bar"))
+
+	gt.String(t, w.String()).
+		Contains(`[req-1] INFO hello, world! foo="bar"`).
+		NotContains(`request_id=`)
+}
+
+func TestPromotedAttrsMissing(t *testing.T) {
+	w := &bytes.Buffer{}
+	tmpl := template.Must(template.New("log").Parse(`[{{.Attrs.request_id}}] {{.Level}} {{.Message}} `))
+	logger := slog.New(clog.New(
+		clog.WithColor(false),
+		clog.WithWriter(w),
+		clog.WithTemplate(tmpl),
+		clog.WithContextExtractor(clog.AttrsFromContext),
+		clog.WithPromotedAttrs("request_id"),
+	))
+
+	logger.Info("hello, world!")
+
+	gt.String(t, w.String()).Equal("[] INFO hello, world! \n")
+}
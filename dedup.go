@@ -0,0 +1,191 @@
+package clog
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"log/slog"
+)
+
+// WithDedup enables deduplication of consecutive identical records. When a record has the same level, message and attributes as the previous one, it is held instead of being written. The last held record is written with a repeat count like "(x42)" when a different record arrives, timeout passes after the last repeat, or Handler.Flush is called. If timeout is 0 or less, held records are not written by timeout.
+func WithDedup(timeout time.Duration) Option {
+	return func(cfg *config) {
+		cfg.dedupEnabled = true
+		cfg.dedupTimeout = timeout
+	}
+}
+
+// WithDedupRewrite enables rewriting the previous output in place with the repeat count instead of holding repeated records. It works only when all writers of the record are terminals, and repeated records are held as WithDedup otherwise. This option works with WithDedup.
+func WithDedupRewrite(enable bool) Option {
+	return func(cfg *config) {
+		cfg.dedupRewrite = enable
+	}
+}
+
+// deduplicator holds repeated records. It is shared by handlers derived from the same handler.
+type deduplicator struct {
+	timeout time.Duration
+	rewrite bool
+
+	mutex sync.Mutex
+	last  *dedupEntry
+	timer *time.Timer
+}
+
+// dedupEntry is the last written record and its repeats.
+type dedupEntry struct {
+	key      string
+	handler  *Handler
+	resolved *resolvedRecord
+	dests    []destination
+
+	// total is the number of identical records including the first one.
+	total int
+	// held is the number of repeated records that are not written yet.
+	held int
+	// lines is the number of lines of the last output. It is used to rewrite the output.
+	lines int
+}
+
+// handle writes the record or holds it if it is the same as the previous one.
+func (x *deduplicator) handle(h *Handler, ctx context.Context, record slog.Record, t timing, dests []destination) error {
+	resolved := h.resolve(ctx, record, t, h.cfg)
+	key := resolved.fingerprint()
+
+	x.mutex.Lock()
+	defer x.mutex.Unlock()
+
+	if last := x.last; last != nil && last.key == key {
+		last.handler, last.resolved, last.dests = h, resolved, dests
+		last.total++
+
+		if x.rewrite && allTerminal(dests) {
+			return x.writeLast(true)
+		}
+
+		last.held++
+		x.resetTimer()
+		return nil
+	}
+
+	var errs []error
+	if err := x.flushLocked(); err != nil {
+		errs = append(errs, err)
+	}
+
+	lines, err := resolved.renderLines(h.cfg, dests)
+	if err != nil {
+		return errors.Join(append(errs, err)...)
+	}
+	if err := h.writeLines(lines, dests); err != nil {
+		errs = append(errs, err)
+	}
+
+	x.last = &dedupEntry{
+		key:      key,
+		handler:  h,
+		resolved: resolved,
+		dests:    dests,
+		total:    1,
+		lines:    countLines(lines),
+	}
+	return errors.Join(errs...)
+}
+
+// flush writes held records.
+func (x *deduplicator) flush() error {
+	x.mutex.Lock()
+	defer x.mutex.Unlock()
+	return x.flushLocked()
+}
+
+// interrupt writes held records and forgets the last record because other output is written after it.
+func (x *deduplicator) interrupt() error {
+	x.mutex.Lock()
+	defer x.mutex.Unlock()
+
+	err := x.flushLocked()
+	x.last = nil
+	return err
+}
+
+func (x *deduplicator) flushLocked() error {
+	if x.timer != nil {
+		x.timer.Stop()
+	}
+	if x.last == nil || x.last.held == 0 {
+		return nil
+	}
+	return x.writeLast(false)
+}
+
+// writeLast writes the last record with the repeat count. If rewrite is true, the previous output is overwritten.
+func (x *deduplicator) writeLast(rewrite bool) error {
+	last := x.last
+	lines, err := last.resolved.renderLines(last.handler.cfg, last.dests)
+	if err != nil {
+		return err
+	}
+
+	for color, line := range lines {
+		line = append(bytes.TrimRight(line, " \n"), fmt.Sprintf(" (x%d)\n", last.total)...)
+		if rewrite && last.lines > 0 {
+			// move the cursor to the beginning of the previous output and clear the rest of screen
+			line = append(fmt.Appendf(nil, "\x1b[%dA\r\x1b[J", last.lines), line...)
+		}
+		lines[color] = line
+	}
+
+	last.held = 0
+	last.lines = countLines(lines)
+	return last.handler.writeLines(lines, last.dests)
+}
+
+func (x *deduplicator) resetTimer() {
+	if x.timeout <= 0 {
+		return
+	}
+	if x.timer == nil {
+		x.timer = time.AfterFunc(x.timeout, func() {
+			_ = x.flush()
+		})
+		return
+	}
+	x.timer.Reset(x.timeout)
+}
+
+// fingerprint builds a key of the resolved record from level, message and attributes to compare records. Time, color and the printer are not included.
+func (x *resolvedRecord) fingerprint() string {
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, "%s %q", x.log.logLevel, x.log.Message)
+	for _, op := range x.ops {
+		if op.kind != opPrint {
+			continue
+		}
+		fmt.Fprintf(buf, " %q.%q=%q", strings.Join(op.groups, "."), op.attr.Key, op.attr.Value.String())
+	}
+	buf.Write(x.deferred)
+	return buf.String()
+}
+
+func allTerminal(dests []destination) bool {
+	for _, dst := range dests {
+		if !dst.terminal {
+			return false
+		}
+	}
+	return len(dests) > 0
+}
+
+// countLines returns the number of lines of the rendered output. All lines of a record have the same number of lines regardless of color.
+func countLines(lines map[bool][]byte) int {
+	for _, line := range lines {
+		return bytes.Count(line, []byte("\n"))
+	}
+	return 0
+}
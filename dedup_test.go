@@ -0,0 +1,150 @@
+package clog_test
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"text/template"
+	"time"
+
+	"github.com/m-mizutani/clog"
+	"github.com/m-mizutani/gt"
+)
+
+// syncBuffer is a bytes.Buffer that can be read while written by another goroutine.
+type syncBuffer struct {
+	mutex sync.Mutex
+	buf   bytes.Buffer
+}
+
+func (x *syncBuffer) Write(p []byte) (int, error) {
+	x.mutex.Lock()
+	defer x.mutex.Unlock()
+	return x.buf.Write(p)
+}
+
+func (x *syncBuffer) String() string {
+	x.mutex.Lock()
+	defer x.mutex.Unlock()
+	return x.buf.String()
+}
+
+var dedupTestTmpl = template.Must(template.New("dedup").Parse(`{{.Level}} {{.Message}} `))
+
+func TestDedup(t *testing.T) {
+	var buf bytes.Buffer
+	h := clog.New(
+		clog.WithWriter(&buf),
+		clog.WithColor(false),
+		clog.WithTemplate(dedupTestTmpl),
+		clog.WithDedup(0),
+	)
+	logger := slog.New(h)
+
+	for i := 0; i < 42; i++ {
+		logger.Warn("retrying", slog.String("host", "db"))
+	}
+	gt.S(t, buf.String()).Equal("WARN retrying host=\"db\" \n")
+
+	logger.Warn("retrying", slog.String("host", "cache"))
+	logger.With("host", "cache").Warn("retrying")
+	logger.Info("connected")
+	gt.NoError(t, h.Flush())
+
+	gt.S(t, buf.String()).Equal(strings.Join([]string{
+		`WARN retrying host="db" `,
+		`WARN retrying host="db" (x42)`,
+		`WARN retrying host="cache" `,
+		`WARN retrying host="cache" (x2)`,
+		`INFO connected `,
+		"",
+	}, "\n"))
+}
+
+func TestDedupFlush(t *testing.T) {
+	var buf bytes.Buffer
+	h := clog.New(
+		clog.WithWriter(&buf),
+		clog.WithColor(false),
+		clog.WithTemplate(dedupTestTmpl),
+		clog.WithDedup(0),
+	)
+	logger := slog.New(h)
+
+	logger.Info("tick")
+	logger.Info("tick")
+	logger.Info("tick")
+	gt.NoError(t, h.Flush())
+	gt.NoError(t, h.Flush())
+
+	gt.S(t, buf.String()).Equal("INFO tick \nINFO tick (x3)\n")
+}
+
+func TestDedupTimeout(t *testing.T) {
+	var buf syncBuffer
+	logger := slog.New(clog.New(
+		clog.WithWriter(&buf),
+		clog.WithColor(false),
+		clog.WithTemplate(dedupTestTmpl),
+		clog.WithDedup(10*time.Millisecond),
+	))
+
+	logger.Info("tick")
+	logger.Info("tick")
+
+	deadline := time.Now().Add(time.Second)
+	for !strings.Contains(buf.String(), "(x2)") && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	gt.S(t, buf.String()).Equal("INFO tick \nINFO tick (x2)\n")
+}
+
+func TestDedupRewriteFallback(t *testing.T) {
+	var buf bytes.Buffer
+	h := clog.New(
+		clog.WithWriter(&buf),
+		clog.WithColor(false),
+		clog.WithTemplate(dedupTestTmpl),
+		clog.WithDedup(0),
+		clog.WithDedupRewrite(true),
+	)
+	logger := slog.New(h)
+
+	logger.Info("tick")
+	logger.Info("tick")
+	logger.Info("tock")
+
+	// the buffer is not a terminal, so repeated records are held instead of rewriting
+	gt.S(t, buf.String()).
+		Equal("INFO tick \nINFO tick (x2)\nINFO tock \n").
+		NotContains("\x1b[")
+}
+
+func TestDedupResolvesOnce(t *testing.T) {
+	var buf bytes.Buffer
+	var hooked, valued int
+	h := clog.New(
+		clog.WithWriter(&buf),
+		clog.WithColor(false),
+		clog.WithTemplate(dedupTestTmpl),
+		clog.WithDedup(0),
+		clog.WithAttrHook(func(groups []string, attr slog.Attr) *clog.HandleAttr {
+			if attr.Key == "user" {
+				hooked++
+			}
+			return nil
+		}),
+	)
+	logger := slog.New(h)
+
+	logger.Info("tick", "user", "alice")
+	logger.Info("tick", "user", "alice")
+	logger.Info("tick", "n", countValuer{calls: &valued})
+	gt.NoError(t, h.Flush())
+
+	gt.V(t, hooked).Equal(2)
+	gt.V(t, valued).Equal(1)
+	gt.S(t, buf.String()).Equal("INFO tick user=\"alice\" \nINFO tick user=\"alice\" (x2)\nINFO tick n=1 \n")
+}
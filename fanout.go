@@ -0,0 +1,70 @@
+package clog
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	"log/slog"
+)
+
+// Fanout creates a slog.Handler that dispatches each record to all handlers, e.g. colored console output by Handler and JSON output to a file by slog.JSONHandler. A record is passed only to handlers that are enabled for its level, and errors from all handlers are joined.
+func Fanout(handlers ...slog.Handler) slog.Handler {
+	return &fanoutHandler{
+		handlers: handlers,
+	}
+}
+
+type fanoutHandler struct {
+	handlers []slog.Handler
+}
+
+var _ slog.Handler = (*fanoutHandler)(nil)
+
+// Enabled implements slog.Handler. It returns true if any of handlers is enabled for the level.
+func (x *fanoutHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, h := range x.handlers {
+		if h.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+// Handle implements slog.Handler.
+func (x *fanoutHandler) Handle(ctx context.Context, record slog.Record) error {
+	var errs []error
+	for _, h := range x.handlers {
+		if !h.Enabled(ctx, record.Level) {
+			continue
+		}
+		// each handler gets its own copy because a handler may add attributes to the record
+		if err := h.Handle(ctx, record.Clone()); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// WithAttrs implements slog.Handler.
+func (x *fanoutHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handlers := make([]slog.Handler, len(x.handlers))
+	for i, h := range x.handlers {
+		// a handler owns the given slice, so it must not be shared between handlers
+		handlers[i] = h.WithAttrs(slices.Clone(attrs))
+	}
+	return &fanoutHandler{handlers: handlers}
+}
+
+// WithGroup implements slog.Handler.
+func (x *fanoutHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return x
+	}
+
+	handlers := make([]slog.Handler, len(x.handlers))
+	for i, h := range x.handlers {
+		handlers[i] = h.WithGroup(name)
+	}
+	return &fanoutHandler{handlers: handlers}
+}
@@ -0,0 +1,63 @@
+package clog_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/m-mizutani/clog"
+	"github.com/m-mizutani/gt"
+)
+
+func TestFanout(t *testing.T) {
+	var console, file bytes.Buffer
+	logger := slog.New(clog.Fanout(
+		clog.New(
+			clog.WithColor(false),
+			clog.WithWriter(&console),
+		),
+		slog.NewJSONHandler(&file, &slog.HandlerOptions{Level: slog.LevelDebug}),
+	))
+
+	logger.With("service", "api").WithGroup("req").Info("hello, world!", slog.String("path", "/"))
+	gt.S(t, console.String()).Contains(`service="api" req.path="/"`)
+
+	var out map[string]any
+	gt.NoError(t, json.Unmarshal(file.Bytes(), &out))
+	gt.V(t, out["msg"]).Equal("hello, world!")
+	gt.V(t, out["service"]).Equal("api")
+	gt.V(t, out["req"]).Equal(map[string]any{"path": "/"})
+
+	console.Reset()
+	file.Reset()
+	logger.Debug("debug message")
+	gt.S(t, console.String()).Equal("")
+	gt.S(t, file.String()).Contains("debug message")
+}
+
+func TestFanoutEnabled(t *testing.T) {
+	h := clog.Fanout(
+		clog.New(clog.WithLevel(slog.LevelWarn)),
+		clog.New(clog.WithLevel(slog.LevelError)),
+	)
+
+	ctx := context.Background()
+	gt.B(t, h.Enabled(ctx, slog.LevelInfo)).False()
+	gt.B(t, h.Enabled(ctx, slog.LevelWarn)).True()
+	gt.B(t, clog.Fanout().Enabled(ctx, slog.LevelError)).False()
+}
+
+func TestFanoutError(t *testing.T) {
+	var buf bytes.Buffer
+	h := clog.Fanout(
+		clog.New(clog.WithColor(false), clog.WithWriter(errWriter{})),
+		clog.New(clog.WithColor(false), clog.WithWriter(&buf)),
+	)
+
+	err := h.Handle(context.Background(), slog.NewRecord(time.Now(), slog.LevelInfo, "hello", 0))
+	gt.Error(t, err)
+	gt.S(t, buf.String()).Contains("hello")
+}
@@ -7,12 +7,14 @@ require (
 	github.com/k0kubun/pp/v3 v3.5.0
 	github.com/m-mizutani/goerr/v2 v2.0.0
 	github.com/m-mizutani/gt v0.0.7
+	github.com/mattn/go-isatty v0.0.20
+	go.opentelemetry.io/otel/trace v1.38.0
+	golang.org/x/sys v0.40.0
 )
 
 require (
-	github.com/google/go-cmp v0.6.0 // indirect
+	github.com/google/go-cmp v0.7.0 // indirect
 	github.com/mattn/go-colorable v0.1.14 // indirect
-	github.com/mattn/go-isatty v0.0.20 // indirect
-	golang.org/x/sys v0.40.0 // indirect
+	go.opentelemetry.io/otel v1.38.0 // indirect
 	golang.org/x/text v0.33.0 // indirect
 )
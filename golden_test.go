@@ -0,0 +1,169 @@
+package clog_test
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"text/template"
+	"time"
+
+	"github.com/m-mizutani/clog"
+	"github.com/m-mizutani/gt"
+)
+
+var update = flag.Bool("update", false, "update golden files in testdata/golden")
+
+var goldenTime = time.Date(2024, 1, 2, 3, 4, 5, 678000000, time.UTC)
+
+// stepClock is a Clock that advances by step on each call of Now.
+type stepClock struct {
+	mutex sync.Mutex
+	now   time.Time
+	step  time.Duration
+}
+
+func (x *stepClock) Now() time.Time {
+	x.mutex.Lock()
+	defer x.mutex.Unlock()
+	now := x.now
+	x.now = x.now.Add(x.step)
+	return now
+}
+
+type goldenUser struct {
+	Name  string
+	Email string
+}
+
+// goldenSpanKey is a context key of fixed trace and span IDs of golden records.
+type goldenSpanKey struct{}
+
+type goldenSpan struct {
+	traceID, spanID string
+}
+
+// goldenTraceContext returns trace and span IDs set to ctx by writeGoldenRecords.
+func goldenTraceContext(ctx context.Context) (traceID, spanID string) {
+	span, _ := ctx.Value(goldenSpanKey{}).(goldenSpan)
+	return span.traceID, span.spanID
+}
+
+func writeGoldenRecords(t *testing.T, h slog.Handler) {
+	ctx := context.WithValue(context.Background(), goldenSpanKey{}, goldenSpan{
+		traceID: "4bf92f3577b34da6a3ce929d0e0e4736",
+		spanID:  "00f067aa0ba902b7",
+	})
+	handle := func(h slog.Handler, level slog.Level, msg string, attrs ...slog.Attr) {
+		t.Helper()
+		record := slog.NewRecord(goldenTime, level, msg, 0)
+		record.AddAttrs(attrs...)
+		gt.NoError(t, h.Handle(ctx, record))
+	}
+
+	handle(h, slog.LevelInfo, "plain attributes",
+		slog.String("str", "hello"),
+		slog.Int("int", 42),
+		slog.Bool("bool", true),
+		slog.Float64("float", 3.14),
+		slog.Duration("duration", 1500*time.Millisecond),
+		slog.Time("time", goldenTime),
+		slog.Any("struct", goldenUser{Name: "alice", Email: "alice@example.com"}),
+	)
+	handle(h, slog.LevelDebug, "nested groups",
+		slog.Group("outer",
+			slog.String("key", "value"),
+			slog.Group("inner", slog.Int("n", 1)),
+		),
+	)
+	handle(h.WithAttrs([]slog.Attr{slog.String("service", "api")}).WithGroup("req"), slog.LevelWarn, "handler groups",
+		slog.String("path", "/users"),
+		slog.Int("status", 404),
+	)
+	handle(h, slog.LevelError, "hook and replaceAttr",
+		slog.String("secret", "my-token"),
+		slog.String("password", "p@ssw0rd"),
+	)
+	handle(h, slog.LevelError+2, "custom level")
+}
+
+func TestGolden(t *testing.T) {
+	enableColorOutput(t)
+
+	printers := []struct {
+		name   string
+		option clog.Option
+	}{
+		{"linear", clog.WithPrinter(clog.LinearPrinter)},
+		{"pretty", clog.WithPrinter(clog.PrettyPrinter)},
+		{"indent", clog.WithPrinter(clog.IndentPrinter)},
+	}
+	templates := []struct {
+		name string
+		text string
+	}{
+		{"standard", clog.TemplateStandard},
+		{"time", clog.TemplateStandardWithTime},
+		{"elapsed", clog.TemplateStandardWithElapsed},
+		{"trace", clog.TemplateStandardWithTrace},
+		{"delta", clog.TemplateStandardWithDelta},
+	}
+
+	hook := func(_ []string, attr slog.Attr) *clog.HandleAttr {
+		if attr.Key != "secret" {
+			return nil
+		}
+		newAttr := slog.String("secret", "***")
+		return &clog.HandleAttr{
+			NewAttr: &newAttr,
+			Defer: func(w io.Writer) {
+				_, _ = fmt.Fprint(w, "(secret was hidden)")
+			},
+		}
+	}
+	replaceAttr := func(_ []string, attr slog.Attr) slog.Attr {
+		if attr.Key == "password" {
+			return slog.String(attr.Key, "[REDACTED]")
+		}
+		return attr
+	}
+
+	for _, printer := range printers {
+		for _, color := range []bool{false, true} {
+			for _, tmpl := range templates {
+				name := fmt.Sprintf("%s_%s_color-%v", printer.name, tmpl.name, color)
+				t.Run(name, func(t *testing.T) {
+					var buf bytes.Buffer
+					h := clog.New(
+						clog.WithWriter(&buf),
+						clog.WithLevel(slog.LevelDebug),
+						clog.WithColor(color),
+						clog.WithClock(&stepClock{now: goldenTime, step: 250 * time.Millisecond}),
+						clog.WithTemplate(template.Must(template.New(tmpl.name).Parse(tmpl.text))),
+						clog.WithAttrHook(hook),
+						clog.WithReplaceAttr(replaceAttr),
+						clog.WithTraceContext(goldenTraceContext),
+						printer.option,
+					)
+					writeGoldenRecords(t, h)
+
+					path := filepath.Join("testdata", "golden", name+".golden")
+					if *update {
+						gt.NoError(t, os.WriteFile(path, buf.Bytes(), 0644))
+						return
+					}
+
+					expected, err := os.ReadFile(path)
+					gt.NoError(t, err).Must()
+					gt.S(t, buf.String()).Equal(string(expected))
+				})
+			}
+		}
+	}
+}
@@ -3,10 +3,13 @@ package clog
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"path/filepath"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"log/slog"
 
@@ -22,6 +25,11 @@ type Handler struct {
 	mutex *sync.Mutex
 
 	parent *Handler
+
+	// live holds the latest config if the handler is created by Watch. It is shared with derived handlers.
+	live *atomic.Pointer[config]
+	// latest caches the copy of the handler with the latest config of live, so that the copy is not allocated for every record. It is nil for temporary clones.
+	latest *atomic.Pointer[Handler]
 }
 
 var _ slog.Handler = (*Handler)(nil)
@@ -36,6 +44,10 @@ func New(options ...Option) *Handler {
 	for _, option := range options {
 		option(h.cfg)
 	}
+	h.cfg.finalize()
+	if ctrl, ok := h.cfg.level.(*LevelController); ok {
+		ctrl.attach(h)
+	}
 
 	return h
 }
@@ -46,16 +58,72 @@ func (x *Handler) clone() *Handler {
 		cfg:    x.cfg,
 		parent: x,
 		mutex:  x.mutex,
+		live:   x.live,
 	}
 
 	return newHandler
 }
 
-// Enabled implements slog.Handler.
+// current returns the handler with the latest config swapped by Watch. The config is loaded once so that handling a record is not affected by a reload in the middle of it.
+func (x *Handler) current() *Handler {
+	if x.live == nil {
+		return x
+	}
+	cfg := x.live.Load()
+	if cfg == x.cfg {
+		return x
+	}
+	if x.latest != nil {
+		if h := x.latest.Load(); h != nil && h.cfg == cfg {
+			return h
+		}
+	}
+	h := *x
+	h.cfg = cfg
+	if x.latest != nil {
+		x.latest.Store(&h)
+	}
+	return &h
+}
+
+// derive returns a new handler derived from the handler with the latest config.
+func (x *Handler) derive() *Handler {
+	newHandler := x.current().clone()
+	if newHandler.live != nil {
+		newHandler.latest = &atomic.Pointer[Handler]{}
+	}
+	return newHandler
+}
+
+// root returns the handler created by New.
+func (x *Handler) root() *Handler {
+	for x.parent != nil {
+		x = x.parent
+	}
+	return x
+}
+
+// Enabled implements slog.Handler. It also returns true for levels captured by the ring buffer specified by WithRing.
 func (x *Handler) Enabled(ctx context.Context, level slog.Level) bool {
+	x = x.current()
+	if x.cfg.ring != nil && x.cfg.ring.level.Level() <= level {
+		return true
+	}
+	if ctrl, ok := x.cfg.level.(*LevelController); ok {
+		// records of packages with a lower level are filtered by Handle
+		return ctrl.minLevel() <= level
+	}
 	return x.cfg.level.Level() <= level
 }
 
+// levelEnabled returns true if the record has the level to be written. Package rules of LevelController are applied by PC of the record.
+func (x *Handler) levelEnabled(record slog.Record) bool {
+	if ctrl, ok := x.cfg.level.(*LevelController); ok {
+		return ctrl.enabled(record.Level, record.PC)
+	}
+	return x.cfg.level.Level() <= record.Level
+}
+
 type stack struct {
 	handlers []*Handler
 }
@@ -76,86 +144,384 @@ func (x *stack) pop() *Handler {
 
 // Handle implements slog.Handler.
 func (x *Handler) Handle(ctx context.Context, record slog.Record) error {
-	x = x.clone()
-	buf := &bytes.Buffer{}
+	x = x.current()
+	if x.cfg.ring != nil && !x.levelEnabled(record) {
+		// records below the level are only captured by the ring buffer
+		x.cfg.ring.push(x, ctx, record)
+		return nil
+	}
+	if _, ok := x.cfg.level.(*LevelController); ok && !x.levelEnabled(record) {
+		return nil
+	}
 
-	log := &Log{
-		logLevel:  record.Level,
-		Timestamp: record.Time.Format(x.cfg.timeFmt),
-		Elapsed:   elapsedDuration(),
-		Level:     x.cfg.levelFormatter(record.Level),
-		Message:   record.Message,
+	var errs []error
+	if x.cfg.sampler != nil {
+		now := x.cfg.clock.Now()
+		pass, summaries := x.cfg.sampler.sample(record.Level, record.Message, now, x)
+
+		if err := x.writeSummaries(summaries, now); err != nil {
+			errs = append(errs, err)
+		}
+		if !pass {
+			return errors.Join(errs...)
+		}
 	}
-	if record.Time.IsZero() {
-		log.Timestamp = "(no time)"
+
+	dests := x.cfg.destinations(record.Level)
+
+	if x.cfg.ring != nil && x.cfg.ring.triggeredBy(record.Level) {
+		// flight recorder: records captured before this one are written first
+		entries := x.cfg.ring.drain()
+		if len(entries) > 0 && x.cfg.dedup != nil {
+			if err := x.cfg.dedup.interrupt(); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		for _, entry := range entries {
+			if err := entry.handler.write(entry.ctx, entry.record, entry.timing, dests); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	if x.cfg.status != nil && allTerminal(dests) {
+		if progress, ok := findProgress(record); ok {
+			if x.cfg.dedup != nil {
+				if err := x.cfg.dedup.interrupt(); err != nil {
+					errs = append(errs, err)
+				}
+			}
+			if err := x.writeStatus(ctx, record, progress, x.cfg.timing(), dests); err != nil {
+				errs = append(errs, err)
+			}
+			return errors.Join(errs...)
+		}
 	}
+	if x.cfg.dedup != nil {
+		if err := x.cfg.dedup.handle(x, ctx, record, x.cfg.timing(), dests); err != nil {
+			errs = append(errs, err)
+		}
+	} else if err := x.write(ctx, record, x.cfg.timing(), dests); err != nil {
+		errs = append(errs, err)
+	}
+
+	return errors.Join(errs...)
+}
+
+// write renders the record and writes it to dests.
+func (x *Handler) write(ctx context.Context, record slog.Record, t timing, dests []destination) error {
+	lines, err := x.resolve(ctx, record, t, x.cfg).renderLines(x.cfg, dests)
+	if err != nil {
+		return err
+	}
+	return x.writeLines(lines, dests)
+}
+
+// writeLines writes lines rendered by renderLines to dests. All writes of a record are done under the lock to keep them atomic.
+func (x *Handler) writeLines(lines map[bool][]byte, dests []destination) error {
+	x.mutex.Lock()
+	defer x.mutex.Unlock()
+
+	var errs []error
+	for _, dst := range dests {
+		b := lines[dst.color]
+		if x.cfg.status != nil {
+			if status, ok := x.cfg.status.lineOn(dst); ok {
+				// clear the status line, write the record above it and draw the status line again
+				b = append(append([]byte(clearLine), b...), status...)
+			}
+		}
+		if _, err := dst.w.Write(b); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// resolvedRecord is a record whose attributes are resolved by AttrHooks, LogValuers, WithReplaceAttr and context extractors. It is rendered for each color setting without resolving them again, so that they run once per record.
+type resolvedRecord struct {
+	// log is the data given to the template without color.
+	log Log
+	// ops are calls of AttrPrinter to print the attributes.
+	ops []printOp
+	// deferred is the output of AttrHook's Defer functions.
+	deferred []byte
+}
+
+type printOpKind int
+
+const (
+	opPrint printOpKind = iota
+	opEnterGroup
+	opExitGroup
+)
+
+// printOp is a call of AttrPrinter. groups of opEnterGroup and opExitGroup end with the group.
+type printOp struct {
+	kind   printOpKind
+	groups []string
+	attr   slog.Attr
+	// group is the group attribute of opEnterGroup. It is nil for groups of WithGroup.
+	group *slog.Attr
+}
+
+// play calls the printer by ops. A printer without GroupPrinter receives group attributes by Print instead, and nothing for groups of WithGroup.
+func (x *resolvedRecord) play(p AttrPrinter) {
+	rp, _ := p.(RecordPrinter)
+	gp, _ := p.(GroupPrinter)
+
+	if rp != nil {
+		rp.BeginRecord()
+	}
+	for _, op := range x.ops {
+		switch op.kind {
+		case opPrint:
+			p.Print(op.groups, op.attr)
+		case opEnterGroup:
+			if gp != nil {
+				gp.EnterGroup(op.groups[len(op.groups)-1])
+			} else if op.group != nil {
+				p.Print(op.groups, *op.group)
+			}
+		case opExitGroup:
+			if gp != nil {
+				gp.ExitGroup(op.groups[len(op.groups)-1])
+			}
+		}
+	}
+	if rp != nil {
+		rp.EndRecord()
+	}
+}
+
+// renderLines renders the record once for each color setting of dests.
+func (x *resolvedRecord) renderLines(cfg *config, dests []destination) (map[bool][]byte, error) {
+	lines := make(map[bool][]byte, 2)
+	for _, dst := range dests {
+		if _, ok := lines[dst.color]; ok {
+			continue
+		}
+
+		c := cfg
+		if c.enableColor != dst.color {
+			copied := *cfg
+			copied.enableColor = dst.color
+			c = &copied
+		}
 
-	if x.cfg.addSource && record.PC != 0 {
-		src := getSource(record.PC)
-		log.FileName = filepath.Base(src.FilePath)
-		log.FilePath = src.FilePath
-		log.FuncName = src.Func
-		log.FileLine = src.Line
+		r, err := x.render(c, dst.w)
+		if err != nil {
+			return nil, err
+		}
+		lines[dst.color] = r.line()
 	}
+	return lines, nil
+}
+
+// renderedRecord is a record rendered in parts. It allows exporters to convert each part in a different way.
+type renderedRecord struct {
+	// log is the data given to the template. It is colored if color is enabled.
+	log *Log
+	// header is the output of the template.
+	header []byte
+	// attrs is the output of AttrPrinter.
+	attrs []byte
+	// deferred is the output of AttrHook's Defer functions.
+	deferred []byte
+}
+
+// line returns the parts joined as a line.
+func (x *renderedRecord) line() []byte {
+	buf := &bytes.Buffer{}
+	_, _ = buf.Write(x.header)
+	_, _ = buf.Write(x.attrs)
+	_, _ = buf.Write(x.deferred)
+	fmt.Fprint(buf, "\n")
+	return buf.Bytes()
+}
 
-	if x.cfg.enableColor {
-		log = log.Coloring(x.cfg.colors)
+// renderParts resolves the record with cfg and renders it for dst.
+func (x *Handler) renderParts(ctx context.Context, record slog.Record, t timing, cfg *config, dst io.Writer) (*renderedRecord, error) {
+	return x.resolve(ctx, record, t, cfg).render(cfg, dst)
+}
+
+// resolve builds the template data and resolves attributes of the record and handlers.
+func (x *Handler) resolve(ctx context.Context, record slog.Record, t timing, cfg *config) *resolvedRecord {
+	x = x.clone()
+
+	ts := record.Time
+	if cfg.clockSpecified && !t.now.IsZero() && !ts.IsZero() {
+		// the clock of WithClock overrides the time of the record
+		ts = t.now
+	}
+	log := Log{
+		logLevel:  record.Level,
+		Timestamp: ts.Format(cfg.timeFmt),
+		Elapsed:   t.elapsed,
+		Delta:     t.delta,
+		Level:     cfg.levelFormatter(record.Level),
+		Message:   record.Message,
+	}
+	if record.Time.IsZero() {
+		// a zero time is ignored as slog.Handler requires
+		log.Timestamp = ""
 	}
 
-	if err := x.cfg.tmpl.Execute(buf, log); err != nil {
-		return goerr.Wrap(err, "failed to execute template")
+	if cfg.addSource {
+		var src *source
+		if record.PC != 0 {
+			src = getSource(record.PC)
+		} else if cfg.decodedSource {
+			src = decodedSource(ctx)
+		}
+		if src != nil {
+			log.FileName = filepath.Base(src.FilePath)
+			log.FilePath = src.FilePath
+			log.FuncName = src.Func
+			log.FileLine = src.Line
+		}
 	}
 
-	// print attrs
 	record.Attrs(func(attr slog.Attr) bool {
 		x.attrs = append(x.attrs, attr)
 		return true
 	})
 
+	if ctx != nil && cfg.traceContext != nil {
+		log.TraceID, log.SpanID = cfg.traceContext(ctx)
+		log.ShortTraceID = shortTraceID(log.TraceID)
+	}
+
 	st := &stack{}
 	for handler := x; handler != nil; handler = handler.parent {
 		st.push(handler)
 	}
+	if ctx != nil && len(cfg.ctxExtractors) > 0 {
+		// attributes from context are printed at the root level as if they had been added with WithAttrs
+		root := &Handler{}
+		for _, extract := range cfg.ctxExtractors {
+			root.attrs = append(root.attrs, extract(ctx)...)
+		}
+		st.push(root)
+	}
 
 	p := &printer{
-		hooks: x.cfg.attrHooks,
+		hooks: cfg.attrHooks,
 		resolver: func(g []string, a slog.Attr) slog.Attr {
 			newAttr := slog.Attr{
 				Key:   a.Key,
 				Value: a.Value.Resolve(),
 			}
-			if x.cfg.replaceAttr != nil && newAttr.Value.Kind() != slog.KindGroup {
-				newAttr = x.cfg.replaceAttr(g, newAttr)
+			if cfg.replaceAttr != nil && newAttr.Value.Kind() != slog.KindGroup {
+				newAttr = cfg.replaceAttr(g, newAttr)
 			}
 			return newAttr
 		},
-		attrPrinter: x.cfg.newAttrPrinter(buf, x.cfg),
+		promotedKeys:   cfg.promotedKeys,
+		headerHashKeys: cfg.headerHashKeys,
 	}
-
 	p.printStack(st)
+
+	deferBuf := &bytes.Buffer{}
 	for i := len(p.defers) - 1; i >= 0; i-- {
-		p.defers[i](buf)
+		p.defers[i](deferBuf)
+	}
+	log.Attrs = make(map[string]string, len(cfg.promotedKeys))
+	for key := range cfg.promotedKeys {
+		// missing attributes are printed as empty instead of "<no value>"
+		log.Attrs[key] = ""
 	}
+	for key, value := range p.promoted {
+		log.Attrs[key] = value
+	}
+	log.hashKey = p.headerHashValue
 
-	fmt.Fprint(buf, "\n")
+	return &resolvedRecord{
+		log:      log,
+		ops:      p.ops,
+		deferred: deferBuf.Bytes(),
+	}
+}
 
-	x.mutex.Lock()
-	defer x.mutex.Unlock()
-	if _, err := x.cfg.w.Write(buf.Bytes()); err != nil {
-		return err
+// render formats the resolved record with cfg for dst. dst is given to AttrPrinter by PrinterContext.
+func (x *resolvedRecord) render(cfg *config, dst io.Writer) (*renderedRecord, error) {
+	attrBuf := &bytes.Buffer{}
+	x.play(cfg.newAttrPrinter(attrBuf, &printerContext{cfg: cfg, dst: dst}))
+
+	log := x.log
+	if cfg.enableColor {
+		log.Coloring(cfg.colors)
+	}
+
+	header := &bytes.Buffer{}
+	if err := cfg.tmpl.Execute(header, &log); err != nil {
+		return nil, goerr.Wrap(err, "failed to execute template")
 	}
 
-	return nil
+	return &renderedRecord{
+		log:      &log,
+		header:   header.Bytes(),
+		attrs:    attrBuf.Bytes(),
+		deferred: x.deferred,
+	}, nil
 }
 
 type resolver func(groups []string, attr slog.Attr) slog.Attr
 
+// printer walks attributes of a record and handlers, and records calls of AttrPrinter as ops.
 type printer struct {
-	groups      []string
-	hooks       []AttrHook
-	defers      []func(w io.Writer)
-	resolver    resolver
-	attrPrinter AttrPrinter
+	groups       []string
+	hooks        []AttrHook
+	defers       []func(w io.Writer)
+	resolver     resolver
+	promotedKeys map[string]struct{}
+	promoted     map[string]string
+	ops          []printOp
+
+	// frames are groups of groups with their attributes. The attribute is nil for groups of WithGroup.
+	frames []groupFrame
+	// entered is the number of frames notified to AttrPrinter. Groups are notified when the first attribute in them is printed, so that empty groups are elided.
+	entered int
+
+	headerHashKeys  map[string]struct{}
+	headerHashValue string
+}
+
+type groupFrame struct {
+	name string
+	attr *slog.Attr
+}
+
+// groupPath returns a copy of groups to be kept in ops.
+func (x *printer) groupPath() []string {
+	return append([]string(nil), x.groups...)
+}
+
+// pushGroup starts the group. It is not notified to AttrPrinter until an attribute is printed in it.
+func (x *printer) pushGroup(name string, attr *slog.Attr) {
+	x.groups = append(x.groups, name)
+	x.frames = append(x.frames, groupFrame{name: name, attr: attr})
+}
+
+// popGroup ends the last group, and notifies AttrPrinter if the group has been entered.
+func (x *printer) popGroup() {
+	if x.entered == len(x.frames) {
+		x.ops = append(x.ops, printOp{kind: opExitGroup, groups: x.groupPath()})
+		x.entered--
+	}
+	x.groups = x.groups[:len(x.groups)-1]
+	x.frames = x.frames[:len(x.frames)-1]
+}
+
+// enterGroups notifies AttrPrinter of groups that are not entered yet.
+func (x *printer) enterGroups() {
+	for ; x.entered < len(x.frames); x.entered++ {
+		x.ops = append(x.ops, printOp{
+			kind:   opEnterGroup,
+			groups: append([]string(nil), x.groups[:x.entered+1]...),
+			group:  x.frames[x.entered].attr,
+		})
+	}
 }
 
 func (x *printer) printStack(st *stack) {
@@ -165,7 +531,7 @@ func (x *printer) printStack(st *stack) {
 	}
 
 	if h.group != "" {
-		x.groups = append(x.groups, h.group)
+		x.pushGroup(h.group, nil)
 	}
 
 	for _, attr := range h.attrs {
@@ -174,7 +540,7 @@ func (x *printer) printStack(st *stack) {
 	x.printStack(st)
 
 	if h.group != "" {
-		x.groups = x.groups[:len(x.groups)-1]
+		x.popGroup()
 	}
 }
 
@@ -197,24 +563,97 @@ func (x *printer) printAttr(attr slog.Attr) {
 	}
 
 	attr = x.resolver(x.groups, attr)
+	if attr.Equal(slog.Attr{}) {
+		// LogValuer and WithReplaceAttr can make the attribute empty
+		return
+	}
 
-	if slog.KindGroup == attr.Value.Kind() {
-		x.groups = append(x.groups, attr.Key)
+	if x.headerHashValue == "" && attr.Value.Kind() != slog.KindGroup && matchHashKey(x.headerHashKeys, x.groups, attr.Key) {
+		x.headerHashValue = attr.Value.String()
 	}
 
-	x.attrPrinter.Print(x.groups, attr)
+	if len(x.groups) == 0 && attr.Value.Kind() != slog.KindGroup {
+		if _, ok := x.promotedKeys[attr.Key]; ok {
+			if x.promoted == nil {
+				x.promoted = make(map[string]string)
+			}
+			x.promoted[attr.Key] = attr.Value.String()
+			return
+		}
+	}
 
 	if slog.KindGroup == attr.Value.Kind() {
+		if attr.Key == "" {
+			// attributes of a group with an empty key are inlined
+			for _, a := range attr.Value.Group() {
+				x.printAttr(a)
+			}
+			return
+		}
+
+		x.pushGroup(attr.Key, &attr)
 		for _, a := range attr.Value.Group() {
 			x.printAttr(a)
 		}
-		x.groups = x.groups[:len(x.groups)-1]
+		x.popGroup()
+		return
+	}
+
+	x.enterGroups()
+	x.ops = append(x.ops, printOp{kind: opPrint, groups: x.groupPath(), attr: attr})
+}
+
+// writeSummaries writes summaries of the sampler by the root handler so that they don't have attributes of derived handlers. The context of the record is not used not to add attributes by context extractors.
+func (x *Handler) writeSummaries(summaries []samplingSummary, now time.Time) error {
+	if len(summaries) == 0 {
+		return nil
+	}
+
+	var errs []error
+	if x.cfg.dedup != nil {
+		if err := x.cfg.dedup.interrupt(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	root := x.root().current()
+	for _, summary := range summaries {
+		rec := summary.record(now)
+		if err := root.write(context.Background(), rec, root.cfg.timing(), root.cfg.destinations(rec.Level)); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// writeExpiredSummaries writes summaries of intervals of the sampler that have ended without new records. It is called by the timer of the sampler.
+func (x *Handler) writeExpiredSummaries(sampler *Sampler) {
+	x = x.current()
+	now := x.cfg.clock.Now()
+	_ = x.writeSummaries(sampler.expire(now, x), now)
+}
+
+// Flush writes records held by WithDedup and summaries of records suppressed by WithSampler in the current interval. It should be called before the program exits.
+func (x *Handler) Flush() error {
+	x = x.current()
+
+	var errs []error
+	if x.cfg.dedup != nil {
+		if err := x.cfg.dedup.flush(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if x.cfg.sampler != nil {
+		if err := x.writeSummaries(x.cfg.sampler.flush(), x.cfg.clock.Now()); err != nil {
+			errs = append(errs, err)
+		}
 	}
+	return errors.Join(errs...)
 }
 
 // WithAttrs implements slog.Handler.
 func (x *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
-	newHandler := x.clone()
+	newHandler := x.derive()
 	newHandler.attrs = attrs
 	return newHandler
 }
@@ -225,7 +664,7 @@ func (x *Handler) WithGroup(name string) slog.Handler {
 		return x
 	}
 
-	newHandler := x.clone()
+	newHandler := x.derive()
 	newHandler.group = name
 	return newHandler
 }
@@ -2,11 +2,15 @@ package clog_test
 
 import (
 	"bytes"
+	"context"
 	"testing"
+	"text/template"
+	"time"
 
 	"log/slog"
 
 	"github.com/m-mizutani/clog"
+	"github.com/m-mizutani/clog/internal/decoded"
 	"github.com/m-mizutani/gt"
 )
 
@@ -231,3 +235,70 @@ func TestHandlerWithLevelFormatterAndGroup(t *testing.T) {
 	gt.S(t, output).Contains("grouped message")
 	gt.S(t, output).Contains(`mygroup.key="value"`)
 }
+
+func TestHandlerSourceAttr(t *testing.T) {
+	for _, enabled := range []bool{true, false} {
+		buf := &bytes.Buffer{}
+		handler := clog.New(
+			clog.WithWriter(buf),
+			clog.WithColor(false),
+			clog.WithSource(enabled),
+			clog.WithTemplate(template.Must(template.New("test").Parse(clog.TemplateStandard))),
+		)
+
+		// a source attribute of a record without PC is an ordinary attribute
+		record := slog.NewRecord(time.Now(), slog.LevelInfo, "rebuilt", 0)
+		record.AddAttrs(
+			slog.Any(slog.SourceKey, &slog.Source{Function: "main.main", File: "/src/main.go", Line: 12}),
+			slog.String("foo", "bar"),
+		)
+		// sources of decoded records are used only by the handler of cmd/clog
+		ctx := decoded.WithSource(context.Background(), &slog.Source{File: "/src/other.go", Line: 3})
+		gt.NoError(t, handler.Handle(ctx, record))
+
+		gt.S(t, buf.String()).
+			Contains("INFO rebuilt source=").
+			Contains("foo=\"bar\"").
+			NotContains("[main.go:12]").
+			NotContains("[other.go:3]")
+	}
+}
+
+type groupValuer struct{}
+
+func (groupValuer) LogValue() slog.Value {
+	return slog.GroupValue(slog.Any("inner", tokenValuer("t0ken")), slog.Attr{})
+}
+
+type tokenValuer string
+
+func (x tokenValuer) LogValue() slog.Value {
+	return slog.StringValue("***")
+}
+
+func TestHandlerSlogRules(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := slog.New(clog.New(
+		clog.WithWriter(buf),
+		clog.WithColor(false),
+		clog.WithTemplate(template.Must(template.New("test").Parse(clog.TemplateStandardWithTime))),
+		clog.WithReplaceAttr(func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == "drop" {
+				return slog.Attr{}
+			}
+			return a
+		}),
+	))
+
+	logger.Info("rules",
+		slog.Group("", slog.String("inlined", "a")),
+		slog.Group("g", slog.Any("v", groupValuer{})),
+		slog.String("drop", "b"),
+		slog.Group("empty"),
+	)
+	gt.S(t, buf.String()).Contains(` INFO rules inlined="a" g.v.inner="***" ` + "\n")
+
+	buf.Reset()
+	gt.NoError(t, logger.Handler().Handle(context.Background(), slog.NewRecord(time.Time{}, slog.LevelInfo, "zero time", 0)))
+	gt.S(t, buf.String()).Equal(" INFO zero time \n")
+}
@@ -0,0 +1,187 @@
+package hooks
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"reflect"
+	"strings"
+
+	"github.com/m-mizutani/clog"
+)
+
+// maxErrorDepth limits how deep the error chain is walked to avoid endless loops caused by cyclic Unwrap implementations.
+const maxErrorDepth = 32
+
+// errorChainConfig holds configuration for ErrorChain hook.
+type errorChainConfig struct {
+	withStackTrace bool
+}
+
+// ErrorChainOption is a functional option for ErrorChain hook.
+type ErrorChainOption func(*errorChainConfig)
+
+// WithErrorStackTrace enables or disables stack trace output of the ErrorChain hook.
+// When enabled, the deepest error in each branch that has a StackTrace() method (github.com/pkg/errors style) is printed with its stack trace.
+// Default is false (no stack trace).
+func WithErrorStackTrace(enable bool) ErrorChainOption {
+	return func(cfg *errorChainConfig) {
+		cfg.withStackTrace = enable
+	}
+}
+
+// ErrorChain creates an AttrHook for standard errors. It replaces the error attribute with its message and prints the error chain as a tree after the attributes.
+// The chain is walked with errors.Unwrap style `Unwrap() error` and errors.Join style `Unwrap() []error` methods, and each node shows its concrete type.
+// Use WithErrorStackTrace(true) to include stack trace in the output.
+func ErrorChain(opts ...ErrorChainOption) clog.AttrHook {
+	cfg := &errorChainConfig{
+		withStackTrace: false,
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return func(groups []string, attr slog.Attr) *clog.HandleAttr {
+		if attr.Value.Kind() != slog.KindAny && attr.Value.Kind() != slog.KindLogValuer {
+			return nil
+		}
+		err, ok := attr.Value.Any().(error)
+		if !ok || err == nil {
+			return nil
+		}
+
+		key := strings.Join(append(append([]string{}, groups...), attr.Key), ".")
+		newAttr := slog.String(attr.Key, errorMessage(err))
+		return &clog.HandleAttr{
+			NewAttr: &newAttr,
+			Defer: func(w io.Writer) {
+				_, _ = fmt.Fprintf(w, "\n%s: %s", key, describeError(err))
+				p := &errorTreePrinter{w: w, withStackTrace: cfg.withStackTrace}
+				if p.withStackTrace && stackTraceOf(err) != "" && !p.hasStackBelow(err, 0) {
+					p.printStackTrace(err, "")
+				}
+				p.printChildren(err, "", 0)
+			},
+		}
+	}
+}
+
+type errorTreePrinter struct {
+	w              io.Writer
+	withStackTrace bool
+}
+
+func (x *errorTreePrinter) printChildren(err error, prefix string, depth int) {
+	if depth >= maxErrorDepth {
+		_, _ = fmt.Fprintf(x.w, "\n%s└─ (too deep)", prefix)
+		return
+	}
+
+	children := unwrapErrors(err)
+	for i, child := range children {
+		branch, indent := "├─ ", "│  "
+		if i == len(children)-1 {
+			branch, indent = "└─ ", "   "
+		}
+
+		_, _ = fmt.Fprintf(x.w, "\n%s%s%s", prefix, branch, describeError(child))
+		if x.withStackTrace && stackTraceOf(child) != "" && !x.hasStackBelow(child, depth+1) {
+			x.printStackTrace(child, prefix+indent)
+		}
+		x.printChildren(child, prefix+indent, depth+1)
+	}
+}
+
+func (x *errorTreePrinter) printStackTrace(err error, prefix string) {
+	for _, line := range strings.Split(strings.TrimSpace(stackTraceOf(err)), "\n") {
+		_, _ = fmt.Fprintf(x.w, "\n%s   %s", prefix, strings.ReplaceAll(line, "\t", "  "))
+	}
+}
+
+// hasStackBelow returns true if any descendant of err has a stack trace. Wrapping errors of pkg/errors style usually have their own stack trace, and only the deepest one is useful.
+func (x *errorTreePrinter) hasStackBelow(err error, depth int) bool {
+	if depth >= maxErrorDepth {
+		return false
+	}
+	for _, child := range unwrapErrors(err) {
+		if stackTraceOf(child) != "" || x.hasStackBelow(child, depth+1) {
+			return true
+		}
+	}
+	return false
+}
+
+func unwrapErrors(err error) (children []error) {
+	if isNilPointer(err) {
+		return nil
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			children = nil
+		}
+	}()
+
+	switch x := err.(type) {
+	case interface{ Unwrap() error }:
+		children = append(children, x.Unwrap())
+	case interface{ Unwrap() []error }:
+		children = append(children, x.Unwrap()...)
+	}
+
+	filtered := children[:0]
+	for _, child := range children {
+		if child != nil {
+			filtered = append(filtered, child)
+		}
+	}
+	return filtered
+}
+
+func describeError(err error) string {
+	msg := strings.ReplaceAll(errorMessage(err), "\n", "; ")
+	return fmt.Sprintf("%T: %s", err, msg)
+}
+
+// errorMessage returns err.Error() without panic. A typed nil pointer is "<nil>" and a panic of Error method is a placeholder, the same as fmt.
+func errorMessage(err error) (msg string) {
+	if isNilPointer(err) {
+		return "<nil>"
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			msg = fmt.Sprintf("%%!v(PANIC=Error method: %v)", r)
+		}
+	}()
+	return err.Error()
+}
+
+// isNilPointer returns true if err is a typed nil pointer.
+func isNilPointer(err error) bool {
+	v := reflect.ValueOf(err)
+	return v.Kind() == reflect.Pointer && v.IsNil()
+}
+
+// stackTraceOf returns the formatted stack trace of err if err has `StackTrace() T` method such as github.com/pkg/errors and github.com/m-mizutani/goerr. The method is looked up by reflection to avoid depending on a specific error package. It returns empty for a typed nil pointer and a method that panics, so that the logger never panics.
+func stackTraceOf(err error) (trace string) {
+	if isNilPointer(err) {
+		return ""
+	}
+	method := reflect.ValueOf(err).MethodByName("StackTrace")
+	if !method.IsValid() {
+		return ""
+	}
+	if method.Type().NumIn() != 0 || method.Type().NumOut() != 1 {
+		return ""
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			trace = ""
+		}
+	}()
+	st := method.Call(nil)[0]
+	if st.Kind() == reflect.Slice && st.Len() == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%+v", st.Interface())
+}
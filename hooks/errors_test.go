@@ -0,0 +1,127 @@
+package hooks_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"testing"
+
+	"github.com/m-mizutani/clog"
+	"github.com/m-mizutani/clog/hooks"
+	"github.com/m-mizutani/goerr/v2"
+	"github.com/m-mizutani/gt"
+)
+
+func TestErrorChain(t *testing.T) {
+	newLogger := func(buf *bytes.Buffer, opts ...hooks.ErrorChainOption) *slog.Logger {
+		return slog.New(clog.New(
+			clog.WithWriter(buf),
+			clog.WithColor(false),
+			clog.WithAttrHook(hooks.ErrorChain(opts...)),
+		))
+	}
+
+	t.Run("wrapped chain", func(t *testing.T) {
+		var buf bytes.Buffer
+		_, openErr := os.Open("/no/such/file")
+		err := fmt.Errorf("load config: %w", openErr)
+
+		newLogger(&buf).Error("failed", "err", err)
+		gt.S(t, buf.String()).
+			Contains(`err="load config: open /no/such/file: no such file or directory"`).
+			Contains("\nerr: *fmt.wrapError: load config: open /no/such/file").
+			Contains("\n└─ *fs.PathError: open /no/such/file").
+			Contains("\n   └─ syscall.Errno: no such file or directory")
+	})
+
+	t.Run("joined errors", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := errors.Join(errors.New("first"), errors.New("second"))
+
+		newLogger(&buf).Error("failed", slog.Group("job", slog.Any("err", err)))
+		gt.S(t, buf.String()).
+			Contains(`job.err="first\nsecond"`).
+			Contains("\njob.err: *errors.joinError: first; second").
+			Contains("\n├─ *errors.errorString: first").
+			Contains("\n└─ *errors.errorString: second")
+	})
+
+	t.Run("stack trace", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := fmt.Errorf("outer: %w", goerr.New("inner"))
+
+		newLogger(&buf, hooks.WithErrorStackTrace(true)).Error("failed", "err", err)
+		gt.S(t, buf.String()).
+			Contains("└─ *goerr.Error: inner").
+			Contains("errors_test.go:")
+	})
+
+	t.Run("stack trace disabled by default", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := fmt.Errorf("outer: %w", goerr.New("inner"))
+
+		newLogger(&buf).Error("failed", "err", err)
+		gt.S(t, buf.String()).
+			Contains("└─ *goerr.Error: inner").
+			NotContains("errors_test.go:")
+	})
+
+	t.Run("typed nil and panicking stack trace", func(t *testing.T) {
+		var buf bytes.Buffer
+		var nilErr *tracedError
+		err := errors.Join(nilErr, &tracedError{panics: true})
+
+		newLogger(&buf, hooks.WithErrorStackTrace(true)).Error("failed", "err", err)
+		gt.S(t, buf.String()).
+			Contains("\n├─ *hooks_test.tracedError: <nil>").
+			Contains("\n└─ *hooks_test.tracedError: traced")
+	})
+
+	t.Run("typed nil error", func(t *testing.T) {
+		var buf bytes.Buffer
+		var nilErr *messageError
+
+		newLogger(&buf).Error("failed", "err", error(nilErr))
+		gt.S(t, buf.String()).
+			Contains(`err="<nil>"`).
+			Contains("\nerr: *hooks_test.messageError: <nil>")
+
+		buf.Reset()
+		newLogger(&buf).Error("failed", "err", fmt.Errorf("wrapped: %w", nilErr))
+		gt.S(t, buf.String()).
+			Contains("\n└─ *hooks_test.messageError: <nil>")
+	})
+
+	t.Run("non-error value is not handled", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		newLogger(&buf).Info("hello", "err", "just a string")
+		gt.S(t, buf.String()).
+			Contains(`err="just a string"`).
+			NotContains("\nerr:")
+	})
+}
+
+// messageError dereferences the receiver in Error.
+type messageError struct {
+	msg string
+}
+
+func (x *messageError) Error() string { return x.msg }
+
+// tracedError has a StackTrace method that dereferences the receiver.
+type tracedError struct {
+	panics bool
+	frames []string
+}
+
+func (x *tracedError) Error() string { return "traced" }
+
+func (x *tracedError) StackTrace() []string {
+	if x.panics {
+		panic("broken stack trace")
+	}
+	return x.frames
+}
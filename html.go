@@ -0,0 +1,303 @@
+package clog
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html"
+	"io"
+	"strconv"
+	"strings"
+
+	"log/slog"
+
+	"github.com/fatih/color"
+	"github.com/m-mizutani/goerr/v2"
+)
+
+// RenderHTML renders records as HTML to share logs in web pages and tickets. Colors of ColorMap are converted to inline CSS, attribute groups to collapsible <details> elements, and output of AttrHook's Defer to a <details> element. options are applied as New except WithWriter and WithPrinter, and color is enabled unless WithColor(false) is specified. Elapsed and Delta are computed from the time of records.
+func RenderHTML(w io.Writer, records []slog.Record, options ...Option) error {
+	h := New(options...)
+	cfg := *h.cfg
+	if !cfg.colorSpecified || cfg.enableColor {
+		cfg.enableColor = true
+		cfg.colors = forceColor(cfg.colors)
+	}
+	cfg.newAttrPrinter = newHTMLPrinter
+
+	var buf bytes.Buffer
+	buf.WriteString(`<div class="clog" style="background:#1e1e1e;color:#d4d4d4;font-family:monospace;white-space:pre-wrap;padding:8px">` + "\n")
+	for i, record := range records {
+		r, err := h.renderParts(context.Background(), record, recordTiming(records, i), &cfg, w)
+		if err != nil {
+			return err
+		}
+
+		buf.WriteString(`<div class="clog-record">`)
+		buf.WriteString(ansiToHTML(string(r.header)))
+		buf.Write(r.attrs)
+		if len(r.deferred) > 0 {
+			buf.WriteString(`<details class="clog-deferred"><summary>details</summary>`)
+			buf.WriteString(ansiToHTML(string(r.deferred)))
+			buf.WriteString(`</details>`)
+		}
+		buf.WriteString("</div>\n")
+	}
+	buf.WriteString("</div>\n")
+
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		return goerr.Wrap(err, "failed to write HTML")
+	}
+	return nil
+}
+
+// recordTiming returns elapsed time from the first record and delta from the previous record by time of records.
+func recordTiming(records []slog.Record, i int) timing {
+	var t timing
+	if first := records[0].Time; !first.IsZero() && !records[i].Time.IsZero() {
+		t.elapsed = records[i].Time.Sub(first).Seconds()
+	}
+	if i > 0 && !records[i-1].Time.IsZero() && !records[i].Time.IsZero() {
+		t.delta = records[i].Time.Sub(records[i-1].Time).Seconds()
+	}
+	return t
+}
+
+// forceColor returns a copy of colors that writes ANSI sequences even if color.NoColor is true, so that they can be converted to HTML.
+func forceColor(colors *ColorMap) *ColorMap {
+	if colors == nil {
+		return nil
+	}
+
+	force := func(c *color.Color) *color.Color {
+		if c == nil {
+			return nil
+		}
+		copied := *c
+		copied.EnableColor()
+		return &copied
+	}
+
+	forced := &ColorMap{
+		Level:        make(map[slog.Level]*color.Color, len(colors.Level)),
+		LevelDefault: force(colors.LevelDefault),
+		Time:         force(colors.Time),
+		Message:      force(colors.Message),
+		AttrKey:      force(colors.AttrKey),
+		AttrValue:    force(colors.AttrValue),
+	}
+	for level, c := range colors.Level {
+		forced.Level[level] = force(c)
+	}
+
+	palette := colors.Palette
+	if len(palette) == 0 {
+		palette = defaultPalette
+	}
+	for _, c := range palette {
+		forced.Palette = append(forced.Palette, force(c))
+	}
+	return forced
+}
+
+// htmlPrinter prints attributes as HTML. Groups are printed as nested <details> elements.
+type htmlPrinter struct {
+	basicPrinter
+	depth int
+}
+
+func newHTMLPrinter(w io.Writer, ctx PrinterContext) AttrPrinter {
+	return &htmlPrinter{
+		basicPrinter: newBasicPrinter(w, ctx),
+	}
+}
+
+// EnterGroup implements GroupPrinter. It opens a <details> element of the group.
+func (x *htmlPrinter) EnterGroup(name string) {
+	_, _ = fmt.Fprintf(x.w, `<details open class="clog-group" style="margin-left:1em"><summary>%s</summary>`, html.EscapeString(name))
+	x.depth++
+}
+
+// ExitGroup implements GroupPrinter.
+func (x *htmlPrinter) ExitGroup(name string) {
+	_, _ = io.WriteString(x.w, "</details>")
+	x.depth--
+}
+
+func (x *htmlPrinter) Print(groups []string, attr slog.Attr) {
+	key := colorHTML(x.attrKeyColor(), attr.Key)
+	value := colorHTML(x.ctx.ValueColor(groups, attr), valueToString(attr.Value))
+
+	if x.depth > 0 {
+		_, _ = fmt.Fprintf(x.w, `<div class="clog-attr">%s=%s</div>`, key, value)
+		return
+	}
+	_, _ = fmt.Fprintf(x.w, `<span class="clog-attr">%s=%s</span> `, key, value)
+}
+
+// colorHTML returns HTML of s colored by c.
+func colorHTML(c *color.Color, s string) string {
+	if c == nil {
+		return html.EscapeString(s)
+	}
+	return ansiToHTML(c.Sprint(s))
+}
+
+// ansiToHTML converts text with ANSI SGR sequences to HTML with inline CSS. Other escape sequences are removed.
+func ansiToHTML(s string) string {
+	var (
+		b     strings.Builder
+		style sgrStyle
+		open  bool
+	)
+	for {
+		i := strings.Index(s, "\x1b[")
+		if i < 0 {
+			b.WriteString(html.EscapeString(s))
+			break
+		}
+		b.WriteString(html.EscapeString(s[:i]))
+		s = s[i+2:]
+
+		end := strings.IndexFunc(s, func(r rune) bool { return r >= 0x40 && r <= 0x7e })
+		if end < 0 {
+			break
+		}
+		params, final := s[:end], s[end]
+		s = s[end+1:]
+		if final != 'm' {
+			continue
+		}
+
+		style.apply(params)
+		if open {
+			b.WriteString("</span>")
+			open = false
+		}
+		if css := style.css(); css != "" {
+			fmt.Fprintf(&b, `<span style="%s">`, css)
+			open = true
+		}
+	}
+	if open {
+		b.WriteString("</span>")
+	}
+	return b.String()
+}
+
+// xtermColors are colors of basic and bright ANSI colors in xterm.
+var xtermColors = [16]string{
+	"#000000", "#cd0000", "#00cd00", "#cdcd00", "#0000ee", "#cd00cd", "#00cdcd", "#e5e5e5",
+	"#7f7f7f", "#ff0000", "#00ff00", "#ffff00", "#5c5cff", "#ff00ff", "#00ffff", "#ffffff",
+}
+
+// sgrStyle is a text style set by SGR sequences.
+type sgrStyle struct {
+	fg, bg    string
+	bold      bool
+	faint     bool
+	italic    bool
+	underline bool
+}
+
+func (x *sgrStyle) apply(params string) {
+	var codes []int
+	for _, p := range strings.Split(params, ";") {
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			n = 0
+		}
+		codes = append(codes, n)
+	}
+
+	for i := 0; i < len(codes); i++ {
+		switch n := codes[i]; {
+		case n == 0:
+			*x = sgrStyle{}
+		case n == 1:
+			x.bold = true
+		case n == 2:
+			x.faint = true
+		case n == 3:
+			x.italic = true
+		case n == 4:
+			x.underline = true
+		case n == 22:
+			x.bold, x.faint = false, false
+		case n == 23:
+			x.italic = false
+		case n == 24:
+			x.underline = false
+		case 30 <= n && n <= 37:
+			x.fg = xtermColors[n-30]
+		case n == 39:
+			x.fg = ""
+		case 40 <= n && n <= 47:
+			x.bg = xtermColors[n-40]
+		case n == 49:
+			x.bg = ""
+		case 90 <= n && n <= 97:
+			x.fg = xtermColors[n-90+8]
+		case 100 <= n && n <= 107:
+			x.bg = xtermColors[n-100+8]
+		case n == 38 || n == 48:
+			c, used := extendedColor(codes[i+1:])
+			i += used
+			if n == 38 {
+				x.fg = c
+			} else {
+				x.bg = c
+			}
+		}
+	}
+}
+
+// extendedColor parses parameters of 256 colors ("5;n") or true colors ("2;r;g;b") and returns the color and the number of used parameters.
+func extendedColor(codes []int) (string, int) {
+	switch {
+	case len(codes) >= 2 && codes[0] == 5:
+		return color256(codes[1]), 2
+	case len(codes) >= 4 && codes[0] == 2:
+		return fmt.Sprintf("#%02x%02x%02x", codes[1]&0xff, codes[2]&0xff, codes[3]&0xff), 4
+	}
+	return "", len(codes)
+}
+
+func color256(n int) string {
+	switch {
+	case n < 0 || n > 255:
+		return ""
+	case n < 16:
+		return xtermColors[n]
+	case n < 232:
+		levels := [6]int{0, 95, 135, 175, 215, 255}
+		n -= 16
+		return fmt.Sprintf("#%02x%02x%02x", levels[n/36], levels[n/6%6], levels[n%6])
+	default:
+		gray := 8 + (n-232)*10
+		return fmt.Sprintf("#%02x%02x%02x", gray, gray, gray)
+	}
+}
+
+func (x *sgrStyle) css() string {
+	var props []string
+	if x.fg != "" {
+		props = append(props, "color:"+x.fg)
+	}
+	if x.bg != "" {
+		props = append(props, "background-color:"+x.bg)
+	}
+	if x.bold {
+		props = append(props, "font-weight:bold")
+	}
+	if x.faint {
+		props = append(props, "opacity:0.7")
+	}
+	if x.italic {
+		props = append(props, "font-style:italic")
+	}
+	if x.underline {
+		props = append(props, "text-decoration:underline")
+	}
+	return strings.Join(props, ";")
+}
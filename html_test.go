@@ -0,0 +1,72 @@
+package clog_test
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"text/template"
+	"time"
+
+	"github.com/m-mizutani/clog"
+	"github.com/m-mizutani/gt"
+)
+
+func exportRecords() []slog.Record {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	r1 := slog.NewRecord(now, slog.LevelInfo, "hello <world>", 0)
+	r1.AddAttrs(
+		slog.String("user", "alice"),
+		slog.Group("req", slog.String("path", "/a|b"), slog.Group("header", slog.Int("n", 1))),
+		slog.Int("x", 2),
+	)
+
+	r2 := slog.NewRecord(now.Add(1500*time.Millisecond), slog.LevelError, "failed", 0)
+	r2.AddAttrs(slog.String("user", "bob"), slog.String("trace", "line1\nline2"))
+	return []slog.Record{r1, r2}
+}
+
+// traceHook writes the value of "trace" attribute after attributes.
+func traceHook(groups []string, attr slog.Attr) *clog.HandleAttr {
+	if attr.Key != "trace" {
+		return nil
+	}
+	return &clog.HandleAttr{
+		Defer: func(w io.Writer) {
+			_, _ = io.WriteString(w, "\n"+attr.Value.String())
+		},
+	}
+}
+
+func TestRenderHTML(t *testing.T) {
+	var buf bytes.Buffer
+	gt.NoError(t, clog.RenderHTML(&buf, exportRecords(),
+		clog.WithAttrHook(traceHook),
+		clog.WithTemplate(template.Must(template.New("elapsed").Parse(clog.TemplateStandardWithElapsed))),
+	))
+
+	out := buf.String()
+	gt.S(t, out).
+		Contains(`<span style="color:#00cdcd;font-weight:bold">INFO</span>`).
+		Contains(`<span style="color:#cd0000;font-weight:bold">ERROR</span>`).
+		Contains(`hello &lt;world&gt;`).
+		Contains(`<details open class="clog-group" style="margin-left:1em"><summary>req</summary><div class="clog-attr"><span style="color:#e5e5e5">path</span>=<span style="color:#ffffff">&#34;/a|b&#34;</span></div>` +
+			`<details open class="clog-group" style="margin-left:1em"><summary>header</summary><div class="clog-attr"><span style="color:#e5e5e5">n</span>=<span style="color:#ffffff">1</span></div></details></details>` +
+			`<span class="clog-attr"><span style="color:#e5e5e5">x</span>=<span style="color:#ffffff">2</span></span>`).
+		Contains(`<details class="clog-deferred"><summary>details</summary>` + "\nline1\nline2</details>").
+		Contains("   0.000 ").
+		Contains("   1.500 ").
+		NotContains("\x1b[")
+	gt.N(t, strings.Count(out, `<div class="clog-record">`)).Equal(2)
+	gt.N(t, strings.Count(out, "<details")).Equal(strings.Count(out, "</details>"))
+}
+
+func TestRenderHTMLWithoutColor(t *testing.T) {
+	var buf bytes.Buffer
+	gt.NoError(t, clog.RenderHTML(&buf, exportRecords(), clog.WithColor(false)))
+	gt.S(t, buf.String()).
+		NotContains("<span style=").
+		Contains(`<span class="clog-attr">x=2</span>`)
+}
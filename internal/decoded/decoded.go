@@ -0,0 +1,26 @@
+// Package decoded carries information of records rebuilt from other output, e.g. JSON logs, to the handler. It is used by cmd/clog and not exposed to users.
+package decoded
+
+import (
+	"context"
+	"log/slog"
+)
+
+// SourceOption is a clog.Option that makes the handler print the source set by WithSource for records without PC. It is set by package clog so that other handlers don't look up the context.
+var SourceOption any
+
+type sourceKey struct{}
+
+// WithSource returns a context that has the source location of a record without PC.
+func WithSource(ctx context.Context, src *slog.Source) context.Context {
+	return context.WithValue(ctx, sourceKey{}, src)
+}
+
+// Source returns the source location set by WithSource, or nil.
+func Source(ctx context.Context) *slog.Source {
+	if ctx == nil {
+		return nil
+	}
+	src, _ := ctx.Value(sourceKey{}).(*slog.Source)
+	return src
+}
@@ -0,0 +1,333 @@
+package clog
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"weak"
+
+	"log/slog"
+)
+
+// LevelController is a slog.Leveler that changes the level at runtime. Pass it to WithLevel, and handlers created with it announce each change with a log line. Besides the level, it has per-package rules that override the level for records logged from the packages.
+//
+// LevelController implements http.Handler to get and change levels, and levels can be cycled by signals with HandleSignals.
+type LevelController struct {
+	level slog.LevelVar
+
+	mutex    sync.RWMutex
+	packages map[string]slog.Level
+	// handlers are weak references not to keep handlers that are no longer used
+	handlers []weak.Pointer[Handler]
+
+	// pkgCache caches package paths of PCs
+	pkgCache sync.Map
+}
+
+var _ slog.Leveler = (*LevelController)(nil)
+var _ http.Handler = (*LevelController)(nil)
+
+// NewLevelController creates a LevelController with the level.
+func NewLevelController(level slog.Level) *LevelController {
+	ctrl := &LevelController{}
+	ctrl.level.Set(level)
+	return ctrl
+}
+
+// Level implements slog.Leveler. It returns the level for packages without rules.
+func (x *LevelController) Level() slog.Level {
+	return x.level.Level()
+}
+
+// Set changes the level for packages without rules.
+func (x *LevelController) Set(level slog.Level) {
+	x.set(level, "api")
+}
+
+func (x *LevelController) set(level slog.Level, by string) {
+	x.update(func(slog.Level) slog.Level { return level }, by)
+}
+
+// update swaps the level by next under the lock, so that concurrent changes are announced with the actual previous level. Nothing is announced if the level is not changed.
+func (x *LevelController) update(next func(prev slog.Level) slog.Level, by string) {
+	x.mutex.Lock()
+	prev := x.level.Level()
+	level := next(prev)
+	x.level.Set(level)
+	x.mutex.Unlock()
+
+	if prev != level {
+		x.announce("log level changed", by, slog.String("from", prev.String()), slog.String("to", level.String()))
+	}
+}
+
+// SetPackage sets the level for records logged from the package and its subpackages, e.g. "github.com/example/app/db". The rule of the longest matched package is used.
+func (x *LevelController) SetPackage(pkg string, level slog.Level) {
+	x.setPackage(pkg, level, "api")
+}
+
+func (x *LevelController) setPackage(pkg string, level slog.Level, by string) {
+	x.mutex.Lock()
+	prev, ok := x.packages[pkg]
+	if x.packages == nil {
+		x.packages = make(map[string]slog.Level)
+	}
+	x.packages[pkg] = level
+	x.mutex.Unlock()
+
+	if !ok || prev != level {
+		x.announce("package log level changed", by, slog.String("package", pkg), slog.String("to", level.String()))
+	}
+}
+
+// RemovePackage removes the rule of the package.
+func (x *LevelController) RemovePackage(pkg string) {
+	x.removePackage(pkg, "api")
+}
+
+func (x *LevelController) removePackage(pkg string, by string) {
+	x.mutex.Lock()
+	_, ok := x.packages[pkg]
+	delete(x.packages, pkg)
+	x.mutex.Unlock()
+
+	if ok {
+		x.announce("package log level removed", by, slog.String("package", pkg))
+	}
+}
+
+// Packages returns a copy of per-package rules.
+func (x *LevelController) Packages() map[string]slog.Level {
+	x.mutex.RLock()
+	defer x.mutex.RUnlock()
+
+	packages := make(map[string]slog.Level, len(x.packages))
+	for pkg, level := range x.packages {
+		packages[pkg] = level
+	}
+	return packages
+}
+
+// minLevel returns the lowest level of the level and package rules. Records below it are never written.
+func (x *LevelController) minLevel() slog.Level {
+	lowest := x.level.Level()
+
+	x.mutex.RLock()
+	defer x.mutex.RUnlock()
+	for _, level := range x.packages {
+		if level < lowest {
+			lowest = level
+		}
+	}
+	return lowest
+}
+
+// enabled returns true if the record of the level logged at pc should be written.
+func (x *LevelController) enabled(level slog.Level, pc uintptr) bool {
+	x.mutex.RLock()
+	hasRules := len(x.packages) > 0
+	x.mutex.RUnlock()
+	if !hasRules || pc == 0 {
+		return x.level.Level() <= level
+	}
+
+	pkg := x.packageOf(pc)
+
+	x.mutex.RLock()
+	defer x.mutex.RUnlock()
+
+	threshold, matched := x.level.Level(), ""
+	for rule, ruleLevel := range x.packages {
+		if len(rule) > len(matched) && (pkg == rule || strings.HasPrefix(pkg, rule+"/")) {
+			threshold, matched = ruleLevel, rule
+		}
+	}
+	return threshold <= level
+}
+
+// packageOf returns the package path of the function at pc.
+func (x *LevelController) packageOf(pc uintptr) string {
+	if pkg, ok := x.pkgCache.Load(pc); ok {
+		return pkg.(string)
+	}
+
+	fs := runtime.CallersFrames([]uintptr{pc})
+	f, _ := fs.Next()
+	pkg := packageName(f.Function)
+	x.pkgCache.Store(pc, pkg)
+	return pkg
+}
+
+// packageName extracts the package path from the function name such as "github.com/example/app/db.(*Client).Query".
+func packageName(funcName string) string {
+	slash := strings.LastIndex(funcName, "/")
+	if dot := strings.Index(funcName[slash+1:], "."); dot >= 0 {
+		return funcName[:slash+1+dot]
+	}
+	return funcName
+}
+
+// attach registers the handler to announce changes. Handlers that have been garbage collected are removed.
+func (x *LevelController) attach(h *Handler) {
+	x.mutex.Lock()
+	defer x.mutex.Unlock()
+
+	alive := x.handlers[:0]
+	for _, ref := range x.handlers {
+		if ref.Value() != nil {
+			alive = append(alive, ref)
+		}
+	}
+	clear(x.handlers[len(alive):])
+	x.handlers = append(alive, weak.Make(h))
+}
+
+// announce writes the change to handlers regardless of the level.
+func (x *LevelController) announce(msg, by string, attrs ...slog.Attr) {
+	x.mutex.RLock()
+	handlers := make([]*Handler, 0, len(x.handlers))
+	for _, ref := range x.handlers {
+		if h := ref.Value(); h != nil {
+			handlers = append(handlers, h)
+		}
+	}
+	x.mutex.RUnlock()
+
+	for _, h := range handlers {
+		h = h.current()
+		record := slog.NewRecord(h.cfg.clock.Now(), slog.LevelInfo, msg, 0)
+		record.AddAttrs(attrs...)
+		record.AddAttrs(slog.String("by", by))
+		_ = h.announce(record)
+	}
+}
+
+// announce writes the record bypassing level, sampling and deduplication.
+func (x *Handler) announce(record slog.Record) error {
+	x = x.current()
+	if x.cfg.dedup != nil {
+		if err := x.cfg.dedup.interrupt(); err != nil {
+			return err
+		}
+	}
+	return x.write(context.Background(), record, x.cfg.timing(), x.cfg.destinations(record.Level))
+}
+
+// levelState is the JSON representation of LevelController for ServeHTTP. Fields are pointers and nil map to distinguish omitted fields of PUT.
+type levelState struct {
+	Level    *string           `json:"level"`
+	Packages map[string]string `json:"packages"`
+}
+
+func (x *LevelController) state() levelState {
+	level := x.Level().String()
+	st := levelState{
+		Level:    &level,
+		Packages: make(map[string]string),
+	}
+	for pkg, level := range x.Packages() {
+		st.Packages[pkg] = level.String()
+	}
+	return st
+}
+
+// ServeHTTP implements http.Handler. GET returns levels as JSON like {"level":"INFO","packages":{"github.com/example/app/db":"DEBUG"}}. PUT changes levels by JSON of the same format. Both fields are optional, and "packages" replaces all rules if specified.
+func (x *LevelController) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut:
+		var req levelState
+		dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&req); err != nil {
+			http.Error(w, "invalid request body: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := x.apply(req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	default:
+		w.Header().Set("Allow", "GET, PUT")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(x.state())
+}
+
+// apply changes levels by the request. Nothing is changed if the request has an invalid level.
+func (x *LevelController) apply(req levelState) error {
+	var level slog.Level
+	if req.Level != nil {
+		if err := level.UnmarshalText([]byte(*req.Level)); err != nil {
+			return err
+		}
+	}
+	packages := make(map[string]slog.Level, len(req.Packages))
+	for pkg, s := range req.Packages {
+		var l slog.Level
+		if err := l.UnmarshalText([]byte(s)); err != nil {
+			return err
+		}
+		packages[pkg] = l
+	}
+
+	if req.Level != nil {
+		x.set(level, "http")
+	}
+	if req.Packages != nil {
+		var names []string
+		for pkg := range x.Packages() {
+			names = append(names, pkg)
+		}
+		sort.Strings(names)
+		for _, pkg := range names {
+			if _, ok := packages[pkg]; !ok {
+				x.removePackage(pkg, "http")
+			}
+		}
+
+		names = names[:0]
+		for pkg := range packages {
+			names = append(names, pkg)
+		}
+		sort.Strings(names)
+		for _, pkg := range names {
+			x.setPackage(pkg, packages[pkg], "http")
+		}
+	}
+	return nil
+}
+
+// cycleLevels are levels cycled by Cycle.
+var cycleLevels = []slog.Level{slog.LevelDebug, slog.LevelInfo, slog.LevelWarn, slog.LevelError}
+
+// Cycle changes the level to the next standard level. If verbose is true, the level goes down like INFO to DEBUG and DEBUG wraps to ERROR. Otherwise the level goes up like INFO to WARN and ERROR wraps to DEBUG.
+func (x *LevelController) Cycle(verbose bool) {
+	x.cycle(verbose, "api")
+}
+
+func (x *LevelController) cycle(verbose bool, by string) {
+	x.update(func(current slog.Level) slog.Level {
+		if verbose {
+			for i := len(cycleLevels) - 1; i >= 0; i-- {
+				if cycleLevels[i] < current {
+					return cycleLevels[i]
+				}
+			}
+			return cycleLevels[len(cycleLevels)-1]
+		}
+		for _, level := range cycleLevels {
+			if level > current {
+				return level
+			}
+		}
+		return cycleLevels[0]
+	}, by)
+}
@@ -0,0 +1,172 @@
+package clog_test
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"text/template"
+
+	"github.com/m-mizutani/clog"
+	"github.com/m-mizutani/gt"
+)
+
+var standardTmpl = template.Must(template.New("standard").Parse(clog.TemplateStandard))
+
+func newControlledLogger(ctrl *clog.LevelController) (*slog.Logger, *syncBuffer) {
+	var buf syncBuffer
+	logger := slog.New(clog.New(
+		clog.WithWriter(&buf),
+		clog.WithColor(false),
+		clog.WithTemplate(standardTmpl),
+		clog.WithLevel(ctrl),
+	))
+	return logger, &buf
+}
+
+func TestLevelController(t *testing.T) {
+	ctrl := clog.NewLevelController(slog.LevelInfo)
+	logger, buf := newControlledLogger(ctrl)
+
+	logger.Debug("hidden")
+	ctrl.Set(slog.LevelDebug)
+	logger.Debug("shown")
+	ctrl.Set(slog.LevelDebug)
+	ctrl.Set(slog.LevelError)
+	logger.Warn("hidden")
+
+	gt.S(t, buf.String()).Equal(strings.Join([]string{
+		`INFO log level changed from="INFO" to="DEBUG" by="api" `,
+		`DEBUG shown `,
+		`INFO log level changed from="DEBUG" to="ERROR" by="api" `,
+		``,
+	}, "\n"))
+}
+
+func TestLevelControllerPackage(t *testing.T) {
+	ctrl := clog.NewLevelController(slog.LevelWarn)
+	logger, buf := newControlledLogger(ctrl)
+
+	ctrl.SetPackage("github.com/m-mizutani/other", slog.LevelDebug)
+	logger.Info("hidden by global level")
+
+	ctrl.SetPackage("github.com/m-mizutani/clog_test", slog.LevelDebug)
+	logger.Debug("shown by package rule")
+	gt.V(t, ctrl.Packages()).Equal(map[string]slog.Level{
+		"github.com/m-mizutani/other":     slog.LevelDebug,
+		"github.com/m-mizutani/clog_test": slog.LevelDebug,
+	})
+
+	ctrl.RemovePackage("github.com/m-mizutani/clog_test")
+	logger.Info("hidden again")
+
+	gt.S(t, buf.String()).Equal(strings.Join([]string{
+		`INFO package log level changed package="github.com/m-mizutani/other" to="DEBUG" by="api" `,
+		`INFO package log level changed package="github.com/m-mizutani/clog_test" to="DEBUG" by="api" `,
+		`DEBUG shown by package rule `,
+		`INFO package log level removed package="github.com/m-mizutani/clog_test" by="api" `,
+		``,
+	}, "\n"))
+}
+
+func TestLevelControllerHTTP(t *testing.T) {
+	ctrl := clog.NewLevelController(slog.LevelInfo)
+	logger, buf := newControlledLogger(ctrl)
+	srv := httptest.NewServer(ctrl)
+	t.Cleanup(srv.Close)
+
+	do := func(method, body string) (int, string) {
+		t.Helper()
+		req := gt.R1(http.NewRequest(method, srv.URL, strings.NewReader(body))).NoError(t)
+		resp := gt.R1(http.DefaultClient.Do(req)).NoError(t)
+		defer resp.Body.Close()
+		var out bytes.Buffer
+		gt.R1(out.ReadFrom(resp.Body)).NoError(t)
+		return resp.StatusCode, out.String()
+	}
+
+	code, body := do(http.MethodGet, "")
+	gt.N(t, code).Equal(http.StatusOK)
+	gt.S(t, body).Equal(`{"level":"INFO","packages":{}}` + "\n")
+
+	code, body = do(http.MethodPut, `{"level":"debug","packages":{"github.com/example/db":"WARN"}}`)
+	gt.N(t, code).Equal(http.StatusOK)
+	gt.S(t, body).Equal(`{"level":"DEBUG","packages":{"github.com/example/db":"WARN"}}` + "\n")
+	logger.Debug("debug enabled")
+
+	// omitted level is kept and packages are replaced
+	code, body = do(http.MethodPut, `{"packages":{}}`)
+	gt.N(t, code).Equal(http.StatusOK)
+	gt.S(t, body).Equal(`{"level":"DEBUG","packages":{}}` + "\n")
+
+	for _, invalid := range []string{`{"level":"verbose"}`, `{"packages":{"a":"x"}}`, `{"unknown":1}`, `not json`} {
+		code, _ = do(http.MethodPut, invalid)
+		gt.N(t, code).Equal(http.StatusBadRequest)
+	}
+	code, _ = do(http.MethodPost, `{"level":"info"}`)
+	gt.N(t, code).Equal(http.StatusMethodNotAllowed)
+	gt.V(t, ctrl.Level()).Equal(slog.LevelDebug)
+
+	gt.S(t, buf.String()).Equal(strings.Join([]string{
+		`INFO log level changed from="INFO" to="DEBUG" by="http" `,
+		`INFO package log level changed package="github.com/example/db" to="WARN" by="http" `,
+		`DEBUG debug enabled `,
+		`INFO package log level removed package="github.com/example/db" by="http" `,
+		``,
+	}, "\n"))
+}
+
+func TestLevelControllerCycle(t *testing.T) {
+	ctrl := clog.NewLevelController(slog.LevelInfo)
+
+	var levels []slog.Level
+	for i := 0; i < 4; i++ {
+		ctrl.Cycle(true)
+		levels = append(levels, ctrl.Level())
+	}
+	gt.V(t, levels).Equal([]slog.Level{slog.LevelDebug, slog.LevelError, slog.LevelWarn, slog.LevelInfo})
+
+	ctrl.Set(slog.LevelInfo + 2)
+	ctrl.Cycle(false)
+	gt.V(t, ctrl.Level()).Equal(slog.LevelWarn)
+	ctrl.Cycle(false)
+	ctrl.Cycle(false)
+	gt.V(t, ctrl.Level()).Equal(slog.LevelDebug)
+}
+
+func TestLevelControllerConcurrentSet(t *testing.T) {
+	ctrl := clog.NewLevelController(slog.LevelInfo)
+	_, buf := newControlledLogger(ctrl)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctrl.Set(slog.LevelDebug)
+		}()
+	}
+	wg.Wait()
+
+	gt.S(t, buf.String()).Equal(`INFO log level changed from="INFO" to="DEBUG" by="api" ` + "\n")
+}
+
+func TestLevelControllerReleasesHandlers(t *testing.T) {
+	ctrl := clog.NewLevelController(slog.LevelInfo)
+	var buf syncBuffer
+	func() {
+		// the handler is not referred after this function
+		_ = clog.New(clog.WithWriter(&buf), clog.WithLevel(ctrl))
+	}()
+	runtime.GC()
+
+	_, kept := newControlledLogger(ctrl)
+	ctrl.Set(slog.LevelDebug)
+
+	gt.S(t, buf.String()).Equal("")
+	gt.S(t, kept.String()).Contains("log level changed")
+}
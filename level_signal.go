@@ -0,0 +1,36 @@
+//go:build unix
+
+package clog
+
+import (
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+// HandleSignals cycles the level by signals: SIGUSR1 makes logs more verbose and SIGUSR2 makes them less verbose. See Cycle for the order. It returns a function to stop handling signals. On platforms without these signals such as Windows, it does nothing.
+func (x *LevelController) HandleSignals() (stop func()) {
+	ch := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(ch, syscall.SIGUSR1, syscall.SIGUSR2)
+
+	go func() {
+		for {
+			select {
+			case sig := <-ch:
+				x.cycle(sig == syscall.SIGUSR1, "signal")
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(ch)
+			close(done)
+		})
+	}
+}
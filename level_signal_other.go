@@ -0,0 +1,8 @@
+//go:build !unix
+
+package clog
+
+// HandleSignals does nothing on platforms such as Windows because SIGUSR1 and SIGUSR2 are not available.
+func (x *LevelController) HandleSignals() (stop func()) {
+	return func() {}
+}
@@ -0,0 +1,37 @@
+//go:build unix
+
+package clog_test
+
+import (
+	"log/slog"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/m-mizutani/clog"
+	"github.com/m-mizutani/gt"
+)
+
+func TestLevelControllerSignals(t *testing.T) {
+	ctrl := clog.NewLevelController(slog.LevelInfo)
+	_, buf := newControlledLogger(ctrl)
+	stop := ctrl.HandleSignals()
+	t.Cleanup(stop)
+
+	waitFor := func(expected string) {
+		t.Helper()
+		for i := 0; i < 100 && !strings.Contains(buf.String(), expected); i++ {
+			time.Sleep(10 * time.Millisecond)
+		}
+		gt.S(t, buf.String()).Contains(expected)
+	}
+
+	gt.NoError(t, syscall.Kill(syscall.Getpid(), syscall.SIGUSR1))
+	waitFor(`INFO log level changed from="INFO" to="DEBUG" by="signal"`)
+	gt.V(t, ctrl.Level()).Equal(slog.LevelDebug)
+
+	gt.NoError(t, syscall.Kill(syscall.Getpid(), syscall.SIGUSR2))
+	waitFor(`INFO log level changed from="DEBUG" to="INFO" by="signal"`)
+	gt.V(t, ctrl.Level()).Equal(slog.LevelInfo)
+}
@@ -1,21 +1,27 @@
 package clog
 
 import (
+	"context"
 	"runtime"
-	"time"
 
 	"log/slog"
+
+	"github.com/m-mizutani/clog/internal/decoded"
 )
 
 type Log struct {
 	logLevel slog.Level
+	hashKey  string
 
 	// Timestamp is a time when the log is recorded. Format can be specified by WithTimeFmt.
 	Timestamp string
 
-	// Elapsed is duration from the start of the program.
+	// Elapsed is duration in seconds from the start of the program, or from the creation of the handler if WithClock is specified.
 	Elapsed float64
 
+	// Delta is duration in seconds since the previous record handled by the handler and handlers derived from it. It is 0 for the first record.
+	Delta float64
+
 	// Level is a log level. It is one of "DEBUG", "INFO", "WARN", "ERROR", "FATAL".
 	Level string
 
@@ -33,6 +39,18 @@ type Log struct {
 
 	// FuncName is a function name of the source code that calls logger. It is empty if WithSource is not specified.
 	FuncName string
+
+	// TraceID is a trace ID of the context given to the logger. It is empty if WithTraceContext is not specified or the context has no trace.
+	TraceID string
+
+	// ShortTraceID is the first 8 characters of TraceID. It is colored by hash of TraceID if color is enabled, so lines of the same trace share a color.
+	ShortTraceID string
+
+	// SpanID is a span ID of the context given to the logger. It is empty if WithTraceContext is not specified or the context has no trace.
+	SpanID string
+
+	// Attrs is a map of promoted attribute values keyed by attribute key. Only attributes specified by WithPromotedAttrs are stored, and a key missing in the record has an empty value.
+	Attrs map[string]string
 }
 
 func (x *Log) Coloring(colors *ColorMap) *Log {
@@ -46,21 +64,40 @@ func (x *Log) Coloring(colors *ColorMap) *Log {
 		x.Level = colors.LevelDefault.SprintFunc()(x.Level)
 	}
 
-	if colors.Time != nil {
-		x.Timestamp = colors.Time.SprintFunc()(x.Timestamp)
+	if x.hashKey != "" {
+		// colored by hash of the attribute value specified by WithHeaderHashColor
+		c := colors.hashColor(x.hashKey)
+		x.Timestamp = c.Sprint(x.Timestamp)
+		x.Message = c.Sprint(x.Message)
+		if x.FileName != "" {
+			x.FileName = c.Sprint(x.FileName)
+			x.FilePath = c.Sprint(x.FilePath)
+			x.FuncName = c.Sprint(x.FuncName)
+		}
+	} else {
+		if colors.Time != nil {
+			x.Timestamp = colors.Time.SprintFunc()(x.Timestamp)
+		}
+
+		if colors.Message != nil {
+			x.Message = colors.Message.SprintFunc()(x.Message)
+		}
 	}
 
-	if colors.Message != nil {
-		x.Message = colors.Message.SprintFunc()(x.Message)
+	if x.ShortTraceID != "" {
+		x.ShortTraceID = colors.hashColor(x.TraceID).Sprint(x.ShortTraceID)
 	}
 
 	return x
 }
 
-var initTime = time.Now()
+const shortTraceIDLen = 8
 
-func elapsedDuration() float64 {
-	return time.Since(initTime).Seconds()
+func shortTraceID(traceID string) string {
+	if len(traceID) > shortTraceIDLen {
+		return traceID[:shortTraceIDLen]
+	}
+	return traceID
 }
 
 type source struct {
@@ -69,6 +106,26 @@ type source struct {
 	Line     int
 }
 
+func init() {
+	// the option is private to this module, and only the handler of cmd/clog reads sources of decoded records
+	decoded.SourceOption = Option(func(cfg *config) {
+		cfg.decodedSource = true
+	})
+}
+
+// decodedSource returns the source of a record without PC that is rebuilt by cmd/clog.
+func decodedSource(ctx context.Context) *source {
+	src := decoded.Source(ctx)
+	if src == nil {
+		return nil
+	}
+	return &source{
+		FilePath: src.File,
+		Func:     src.Function,
+		Line:     src.Line,
+	}
+}
+
 func getSource(pc uintptr) *source {
 	fs := runtime.CallersFrames([]uintptr{pc})
 	f, _ := fs.Next()
@@ -0,0 +1,330 @@
+// Package logfmt parses logfmt lines and the default output of clog back into slog records. Attributes with dotted keys such as "req.path" are rebuilt as groups, so records printed by clog.LinearPrinter can be rendered again with other printers or handlers.
+package logfmt
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"log/slog"
+
+	"github.com/m-mizutani/goerr/v2"
+)
+
+// ErrNotRecord is returned when the line is neither logfmt with a message nor clog's output.
+var ErrNotRecord = errors.New("line is not a log record")
+
+type config struct {
+	timeFmt string
+}
+
+// Option is an option of Parse.
+type Option func(cfg *config)
+
+// WithTimeFmt sets the layout of the timestamp in clog's header. It should be the same as clog.WithTimeFmt, and the default is "15:04:05.000" as clog. If the layout is empty, the header has no timestamp like clog.TemplateStandard.
+func WithTimeFmt(layout string) Option {
+	return func(cfg *config) {
+		cfg.timeFmt = layout
+	}
+}
+
+// ansiSGR matches ANSI color sequences written by clog with color enabled.
+var ansiSGR = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+
+// Parse parses a line into a record. The line is either:
+//
+//   - logfmt with "msg" key like `time=2024-01-02T03:04:05Z level=INFO msg="hello" req.path=/api`. "time", "level" and "msg" keys are used for the record and removed from attributes. "source" is converted to an attribute of *slog.Source.
+//   - clog's output of clog.TemplateStandardWithTime and clog.LinearPrinter like `03:04:05.000 INFO [main.go:12] hello req.path="/api"`. The message is text before the first key=value pair.
+//
+// ANSI color sequences are removed before parsing. Values that clog prints with spaces, such as time.Time, can't be restored exactly.
+func Parse(line string, options ...Option) (slog.Record, error) {
+	cfg := &config{timeFmt: "15:04:05.000"}
+	for _, opt := range options {
+		opt(cfg)
+	}
+
+	line = strings.TrimSpace(ansiSGR.ReplaceAllString(line, ""))
+	if line == "" {
+		return slog.Record{}, ErrNotRecord
+	}
+
+	if pairs, err := parsePairs(line); err == nil {
+		return fromPairs(pairs)
+	}
+	return parseHeader(line, cfg)
+}
+
+// ParseAttrs parses logfmt pairs into attributes. Dotted keys are rebuilt as groups in the order of their first appearance.
+func ParseAttrs(s string) ([]slog.Attr, error) {
+	pairs, err := parsePairs(strings.TrimSpace(ansiSGR.ReplaceAllString(s, "")))
+	if err != nil {
+		return nil, err
+	}
+	return buildGroups(pairs), nil
+}
+
+type pair struct {
+	key   string
+	value slog.Value
+}
+
+// fromPairs builds a record from logfmt pairs.
+func fromPairs(pairs []pair) (slog.Record, error) {
+	var (
+		ts     time.Time
+		level  = slog.LevelInfo
+		msg    string
+		hasMsg bool
+		src    *slog.Source
+		rest   []pair
+	)
+	for _, p := range pairs {
+		switch p.key {
+		case slog.TimeKey:
+			if t, err := time.Parse(time.RFC3339Nano, p.value.String()); err == nil {
+				ts = t
+				continue
+			}
+		case slog.LevelKey:
+			var lv slog.Level
+			if err := lv.UnmarshalText([]byte(p.value.String())); err == nil {
+				level = lv
+				continue
+			}
+		case slog.MessageKey:
+			msg, hasMsg = p.value.String(), true
+			continue
+		case slog.SourceKey:
+			if s, ok := parseSource(p.value.String()); ok {
+				src = s
+				continue
+			}
+		}
+		rest = append(rest, p)
+	}
+	if !hasMsg {
+		return slog.Record{}, ErrNotRecord
+	}
+
+	record := slog.NewRecord(ts, level, msg, 0)
+	if src != nil {
+		record.AddAttrs(slog.Any(slog.SourceKey, src))
+	}
+	record.AddAttrs(buildGroups(rest)...)
+	return record, nil
+}
+
+// parseHeader parses clog's header, message and pairs.
+func parseHeader(line string, cfg *config) (slog.Record, error) {
+	rest := line
+
+	// the timestamp is empty for a zero time, and older versions of clog print "(no time)"
+	var ts time.Time
+	if cfg.timeFmt != "" {
+		n := strings.Count(cfg.timeFmt, " ") + 1
+		fields := strings.SplitN(rest, " ", n+1)
+		if len(fields) > n {
+			if t, err := time.Parse(cfg.timeFmt, strings.Join(fields[:n], " ")); err == nil {
+				ts = t
+				rest = fields[n]
+			}
+		}
+		rest = strings.TrimPrefix(rest, "(no time) ")
+	}
+
+	levelText, rest, _ := strings.Cut(strings.TrimLeft(rest, " "), " ")
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(levelText)); err != nil {
+		return slog.Record{}, ErrNotRecord
+	}
+
+	var src *slog.Source
+	if strings.HasPrefix(rest, "[") {
+		if end := strings.Index(rest, "] "); end > 0 {
+			if s, ok := parseSource(rest[1:end]); ok {
+				src = s
+				rest = rest[end+2:]
+			}
+		} else if strings.HasSuffix(rest, "]") {
+			if s, ok := parseSource(rest[1 : len(rest)-1]); ok {
+				src = s
+				rest = ""
+			}
+		}
+	}
+
+	msg, pairs := splitMessage(rest)
+	record := slog.NewRecord(ts, level, msg, 0)
+	if src != nil {
+		record.AddAttrs(slog.Any(slog.SourceKey, src))
+	}
+	record.AddAttrs(buildGroups(pairs)...)
+	return record, nil
+}
+
+// splitMessage splits s into the message and pairs. Pairs start at the first word from which the rest is parsed as logfmt.
+func splitMessage(s string) (string, []pair) {
+	for i := 0; i < len(s); i++ {
+		if i > 0 && s[i-1] != ' ' {
+			continue
+		}
+		if !strings.Contains(s[i:], "=") {
+			break
+		}
+		if pairs, err := parsePairs(s[i:]); err == nil {
+			return strings.TrimRight(s[:i], " "), pairs
+		}
+	}
+	return strings.TrimRight(s, " "), nil
+}
+
+// parseSource parses "file:line" into *slog.Source.
+func parseSource(s string) (*slog.Source, bool) {
+	i := strings.LastIndex(s, ":")
+	if i <= 0 {
+		return nil, false
+	}
+	line, err := strconv.Atoi(s[i+1:])
+	if err != nil {
+		return nil, false
+	}
+	return &slog.Source{File: s[:i], Line: line}, true
+}
+
+// parsePairs parses whole s as space separated key=value pairs.
+func parsePairs(s string) ([]pair, error) {
+	var pairs []pair
+	for i := 0; i < len(s); {
+		if s[i] == ' ' {
+			i++
+			continue
+		}
+
+		eq := strings.IndexByte(s[i:], '=')
+		if eq <= 0 {
+			return nil, goerr.New("missing key", goerr.V("text", s[i:]))
+		}
+		key := s[i : i+eq]
+		if strings.ContainsAny(key, " \"") {
+			return nil, goerr.New("invalid key", goerr.V("key", key))
+		}
+		i += eq + 1
+
+		raw, n, err := scanValue(s[i:])
+		if err != nil {
+			return nil, err
+		}
+		i += n
+		if i < len(s) && s[i] != ' ' {
+			return nil, goerr.New("missing space after value", goerr.V("key", key))
+		}
+
+		pairs = append(pairs, pair{key: key, value: raw})
+	}
+	if len(pairs) == 0 {
+		return nil, goerr.New("no pairs")
+	}
+	return pairs, nil
+}
+
+// scanValue scans a value at the beginning of s and returns it with the scanned length. Quoted values are strings. Unquoted values are converted to bool, int, float or duration if possible, and values in brackets like "[a b]" printed by clog for slices and maps may contain spaces.
+func scanValue(s string) (slog.Value, int, error) {
+	if strings.HasPrefix(s, `"`) {
+		for i := 1; i < len(s); i++ {
+			switch s[i] {
+			case '\\':
+				i++
+			case '"':
+				v, err := strconv.Unquote(s[:i+1])
+				if err != nil {
+					return slog.Value{}, 0, goerr.Wrap(err, "invalid quoted value", goerr.V("value", s[:i+1]))
+				}
+				return slog.StringValue(v), i + 1, nil
+			}
+		}
+		return slog.Value{}, 0, goerr.New("unterminated quoted value", goerr.V("value", s))
+	}
+
+	depth, end := 0, len(s)
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c == '[' || c == '{' {
+			depth++
+		} else if (c == ']' || c == '}') && depth > 0 {
+			depth--
+		} else if c == ' ' && depth == 0 {
+			end = i
+			break
+		}
+	}
+	return unquotedValue(s[:end]), end, nil
+}
+
+func unquotedValue(s string) slog.Value {
+	switch s {
+	case "true":
+		return slog.BoolValue(true)
+	case "false":
+		return slog.BoolValue(false)
+	}
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return slog.Int64Value(i)
+	}
+	if u, err := strconv.ParseUint(s, 10, 64); err == nil {
+		return slog.Uint64Value(u)
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return slog.Float64Value(f)
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return slog.DurationValue(d)
+	}
+	return slog.StringValue(s)
+}
+
+// groupNode is a group being rebuilt from dotted keys.
+type groupNode struct {
+	key      string
+	value    slog.Value
+	children []*groupNode
+	isGroup  bool
+}
+
+func (x *groupNode) child(key string) *groupNode {
+	for _, c := range x.children {
+		if c.isGroup && c.key == key {
+			return c
+		}
+	}
+	c := &groupNode{key: key, isGroup: true}
+	x.children = append(x.children, c)
+	return c
+}
+
+func (x *groupNode) attrs() []slog.Attr {
+	attrs := make([]slog.Attr, 0, len(x.children))
+	for _, c := range x.children {
+		if c.isGroup {
+			attrs = append(attrs, slog.Attr{Key: c.key, Value: slog.GroupValue(c.attrs()...)})
+		} else {
+			attrs = append(attrs, slog.Attr{Key: c.key, Value: c.value})
+		}
+	}
+	return attrs
+}
+
+// buildGroups converts pairs to attributes and rebuilds groups from dotted keys.
+func buildGroups(pairs []pair) []slog.Attr {
+	root := &groupNode{}
+	for _, p := range pairs {
+		path := strings.Split(p.key, ".")
+		node := root
+		for _, group := range path[:len(path)-1] {
+			node = node.child(group)
+		}
+		node.children = append(node.children, &groupNode{key: path[len(path)-1], value: p.value})
+	}
+	return root.attrs()
+}
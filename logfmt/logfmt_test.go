@@ -0,0 +1,123 @@
+package logfmt_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/m-mizutani/clog"
+	"github.com/m-mizutani/clog/logfmt"
+	"github.com/m-mizutani/gt"
+)
+
+func attrsOf(record slog.Record) []slog.Attr {
+	var attrs []slog.Attr
+	record.Attrs(func(attr slog.Attr) bool {
+		attrs = append(attrs, attr)
+		return true
+	})
+	return attrs
+}
+
+func TestParseLogfmt(t *testing.T) {
+	record := gt.R1(logfmt.Parse(`time=2024-01-02T03:04:05.678Z level=WARN source=/src/main.go:12 msg="slow request" req.path=/api req.ms=1.5 n=3 ok=true took=1.5s tags=[a b] note="a \"quoted\" text"`)).NoError(t)
+
+	gt.V(t, record.Time).Equal(time.Date(2024, 1, 2, 3, 4, 5, 678000000, time.UTC))
+	gt.V(t, record.Level).Equal(slog.LevelWarn)
+	gt.S(t, record.Message).Equal("slow request")
+
+	attrs := attrsOf(record)
+	gt.A(t, attrs).Length(7)
+	gt.V(t, attrs[0].Value.Any().(*slog.Source)).Equal(&slog.Source{File: "/src/main.go", Line: 12})
+
+	gt.S(t, attrs[1].Key).Equal("req")
+	group := attrs[1].Value.Group()
+	gt.A(t, group).Length(2)
+	gt.S(t, group[0].Value.String()).Equal("/api")
+	gt.N(t, group[1].Value.Float64()).Equal(1.5)
+
+	gt.N(t, attrs[2].Value.Int64()).Equal(3)
+	gt.B(t, attrs[3].Value.Bool()).True()
+	gt.V(t, attrs[4].Value.Duration()).Equal(1500 * time.Millisecond)
+	gt.S(t, attrs[5].Value.String()).Equal("[a b]")
+	gt.S(t, attrs[6].Value.String()).Equal(`a "quoted" text`)
+}
+
+func TestParseClogOutput(t *testing.T) {
+	record := gt.R1(logfmt.Parse("\x1b[2m03:04:05.678\x1b[0m \x1b[33mWARN\x1b[0m [main.go:12] key=value in message \x1b[36mreq.path\x1b[0m=\"/api\" status=503 \n")).NoError(t)
+
+	gt.S(t, record.Time.Format("15:04:05.000")).Equal("03:04:05.678")
+	gt.V(t, record.Level).Equal(slog.LevelWarn)
+	gt.S(t, record.Message).Equal("key=value in message")
+
+	attrs := attrsOf(record)
+	gt.A(t, attrs).Length(3)
+	gt.V(t, attrs[0].Value.Any().(*slog.Source)).Equal(&slog.Source{File: "main.go", Line: 12})
+	gt.S(t, attrs[1].Key).Equal("req")
+	gt.S(t, attrs[1].Value.Group()[0].Value.String()).Equal("/api")
+	gt.N(t, attrs[2].Value.Int64()).Equal(503)
+}
+
+func TestParseClogOutputWithoutTime(t *testing.T) {
+	for _, line := range []string{
+		" INFO hello user=\"alice\" \n",
+		"(no time) INFO hello user=\"alice\" \n",
+	} {
+		record := gt.R1(logfmt.Parse(line)).NoError(t)
+		gt.B(t, record.Time.IsZero()).True()
+		gt.V(t, record.Level).Equal(slog.LevelInfo)
+		gt.S(t, record.Message).Equal("hello")
+		gt.S(t, attrsOf(record)[0].Value.String()).Equal("alice")
+	}
+}
+
+func TestParseRoundTrip(t *testing.T) {
+	newHandler := func(buf *bytes.Buffer) *clog.Handler {
+		return clog.New(
+			clog.WithWriter(buf),
+			clog.WithColor(false),
+			clog.WithSource(false),
+		)
+	}
+
+	var original bytes.Buffer
+	logger := slog.New(newHandler(&original))
+	logger.Info("hello, world!", slog.String("foo", "bar"), slog.Int("n", 42))
+	logger.WithGroup("req").Warn("slow", slog.String("path", "/api"), slog.Group("user", slog.String("id", "u1")), slog.Duration("took", time.Second))
+	logger.Error("no attrs")
+
+	var rendered bytes.Buffer
+	h := newHandler(&rendered)
+	for _, line := range strings.SplitAfter(original.String(), "\n") {
+		if line == "" {
+			continue
+		}
+		record := gt.R1(logfmt.Parse(line)).NoError(t)
+		gt.NoError(t, h.Handle(context.Background(), record))
+	}
+
+	gt.S(t, rendered.String()).Equal(original.String())
+}
+
+func TestParseNotRecord(t *testing.T) {
+	for _, line := range []string{
+		"",
+		"panic: something wrong",
+		"foo=bar",
+		`msg="unterminated`,
+	} {
+		_, err := logfmt.Parse(line)
+		gt.Error(t, err).Is(logfmt.ErrNotRecord)
+	}
+}
+
+func TestParseAttrs(t *testing.T) {
+	attrs := gt.R1(logfmt.ParseAttrs(`a.b=1 c=x a.d.e="y" a.b2=false`)).NoError(t)
+	gt.S(t, slog.GroupValue(attrs...).String()).Equal("[a=[b=1 d=[e=y] b2=false] c=x]")
+
+	_, err := logfmt.ParseAttrs(`a="x`)
+	gt.Error(t, err)
+}
@@ -0,0 +1,87 @@
+package clog
+
+import (
+	"context"
+	"io"
+	"strconv"
+	"strings"
+
+	"log/slog"
+
+	"github.com/m-mizutani/goerr/v2"
+)
+
+// RenderMarkdown renders records as a Markdown table to share logs in tickets. The table has columns of Time, Level, Source (only with WithSource), Message, one column for each attribute of WithPromotedAttrs in the order of keys, and Attrs. Attrs are printed by LinearPrinter without color, and output of AttrHook's Defer is appended to them with line breaks. options are applied as New except WithWriter, WithPrinter and WithTemplate.
+func RenderMarkdown(w io.Writer, records []slog.Record, options ...Option) error {
+	h := New(options...)
+	cfg := *h.cfg
+	cfg.enableColor = false
+	cfg.newAttrPrinter = LinearPrinter
+
+	header := []string{"Time", "Level"}
+	if cfg.addSource {
+		header = append(header, "Source")
+	}
+	header = append(header, "Message")
+	header = append(header, cfg.promotedOrder...)
+	header = append(header, "Attrs")
+
+	var b strings.Builder
+	writeMarkdownRow(&b, header)
+	separators := make([]string, len(header))
+	for i := range separators {
+		separators[i] = "---"
+	}
+	writeMarkdownRow(&b, separators)
+
+	for i, record := range records {
+		r, err := h.renderParts(context.Background(), record, recordTiming(records, i), &cfg, w)
+		if err != nil {
+			return err
+		}
+
+		row := []string{r.log.Timestamp, r.log.Level}
+		if cfg.addSource {
+			var src string
+			if r.log.FileName != "" {
+				src = r.log.FileName + ":" + strconv.Itoa(r.log.FileLine)
+			}
+			row = append(row, src)
+		}
+		row = append(row, r.log.Message)
+		for _, key := range cfg.promotedOrder {
+			row = append(row, r.log.Attrs[key])
+		}
+		row = append(row, strings.TrimSpace(string(r.attrs)+string(r.deferred)))
+		writeMarkdownRow(&b, row)
+	}
+
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return goerr.Wrap(err, "failed to write Markdown")
+	}
+	return nil
+}
+
+func writeMarkdownRow(b *strings.Builder, cells []string) {
+	b.WriteString("|")
+	for _, cell := range cells {
+		b.WriteString(" ")
+		b.WriteString(escapeMarkdownCell(cell))
+		b.WriteString(" |")
+	}
+	b.WriteString("\n")
+}
+
+// markdownCellReplacer escapes text so that it stays in a table cell. HTML tags are escaped because Markdown renderers such as GitHub accept them.
+var markdownCellReplacer = strings.NewReplacer(
+	"&", "&amp;",
+	"<", "&lt;",
+	">", "&gt;",
+	"|", `\|`,
+	"\r\n", "<br>",
+	"\n", "<br>",
+)
+
+func escapeMarkdownCell(s string) string {
+	return markdownCellReplacer.Replace(s)
+}
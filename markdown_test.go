@@ -0,0 +1,27 @@
+package clog_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/m-mizutani/clog"
+	"github.com/m-mizutani/gt"
+)
+
+func TestRenderMarkdown(t *testing.T) {
+	var buf bytes.Buffer
+	gt.NoError(t, clog.RenderMarkdown(&buf, exportRecords(),
+		clog.WithPromotedAttrs("user"),
+		clog.WithAttrHook(traceHook),
+		clog.WithColor(true),
+	))
+
+	gt.S(t, buf.String()).Equal(strings.Join([]string{
+		`| Time | Level | Message | user | Attrs |`,
+		`| --- | --- | --- | --- | --- |`,
+		`| 03:04:05.000 | INFO | hello &lt;world&gt; | alice | req.path="/a\|b" req.header.n=1 x=2 |`,
+		`| 03:04:06.500 | ERROR | failed | bob | trace="line1\nline2" <br>line1<br>line2 |`,
+		``,
+	}, "\n"))
+}
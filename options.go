@@ -0,0 +1,206 @@
+package clog
+
+import (
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+
+	"log/slog"
+
+	"github.com/fatih/color"
+	"github.com/m-mizutani/goerr/v2"
+)
+
+// Options is the serializable configuration of the handler. It can be loaded from JSON or YAML files and environments, and converted to a handler by NewFromOptions. Empty fields keep the default or the value of other options.
+type Options struct {
+	// Level is the minimum level such as "debug", "info", "warn" and "error". A level with offset such as "info+2" is also accepted.
+	Level string `json:"level,omitempty" yaml:"level,omitempty"`
+	// TimeFormat is the time format of Timestamp in the template.
+	TimeFormat string `json:"time_format,omitempty" yaml:"time_format,omitempty"`
+	// Color enables or disables color output. Color is detected by the writer if nil.
+	Color *bool `json:"color,omitempty" yaml:"color,omitempty"`
+	// Source enables or disables the source location of records.
+	Source *bool `json:"source,omitempty" yaml:"source,omitempty"`
+	// Template is a name of built-in templates ("standard", "time", "elapsed", "trace" and "delta") or template text.
+	Template string `json:"template,omitempty" yaml:"template,omitempty"`
+	// Printer is a name of printers registered by RegisterPrinter. "linear", "pretty" and "indent" are built in.
+	Printer string `json:"printer,omitempty" yaml:"printer,omitempty"`
+	// Theme is a name of ColorMaps registered by RegisterTheme. "default" and "light" are built in.
+	Theme string `json:"theme,omitempty" yaml:"theme,omitempty"`
+}
+
+// templateNames are names of built-in templates available in Options.
+var templateNames = map[string]string{
+	"standard": TemplateStandard,
+	"time":     TemplateStandardWithTime,
+	"elapsed":  TemplateStandardWithElapsed,
+	"trace":    TemplateStandardWithTrace,
+	"delta":    TemplateStandardWithDelta,
+}
+
+var registry = struct {
+	mutex    sync.RWMutex
+	printers map[string]func(io.Writer, PrinterContext) AttrPrinter
+	themes   map[string]*ColorMap
+}{
+	printers: map[string]func(io.Writer, PrinterContext) AttrPrinter{
+		"linear": LinearPrinter,
+		"pretty": PrettyPrinter,
+		"indent": IndentPrinter,
+	},
+	themes: map[string]*ColorMap{},
+}
+
+func init() {
+	// defaultColorMap is initialized by init of color.go
+	registry.themes["default"] = defaultColorMap
+	registry.themes["light"] = &ColorMap{
+		Level: map[slog.Level]*color.Color{
+			slog.LevelDebug: color.New(color.FgHiBlack, color.Bold),
+			slog.LevelInfo:  color.New(color.FgBlue, color.Bold),
+			slog.LevelWarn:  color.New(color.FgMagenta, color.Bold),
+			slog.LevelError: color.New(color.FgRed, color.Bold),
+		},
+		LevelDefault: color.New(color.FgCyan, color.Bold),
+		Time:         color.New(color.FgHiBlack),
+		Message:      color.New(color.FgBlack),
+
+		AttrKey:   color.New(color.FgHiBlack),
+		AttrValue: color.New(color.FgBlack),
+	}
+}
+
+// RegisterPrinter registers the printer by the name to be used in Options. A printer of the same name is replaced.
+func RegisterPrinter(name string, printer func(io.Writer, PrinterContext) AttrPrinter) {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+	registry.printers[name] = printer
+}
+
+// RegisterTheme registers the ColorMap by the name to be used in Options. A theme of the same name is replaced.
+func RegisterTheme(name string, colors *ColorMap) {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+	registry.themes[name] = colors
+}
+
+func lookupPrinter(name string) (func(io.Writer, PrinterContext) AttrPrinter, error) {
+	registry.mutex.RLock()
+	defer registry.mutex.RUnlock()
+	if printer, ok := registry.printers[name]; ok {
+		return printer, nil
+	}
+	return nil, goerr.New("unknown printer", goerr.V("printer", name), goerr.V("available", registeredNames(registry.printers)))
+}
+
+func lookupTheme(name string) (*ColorMap, error) {
+	registry.mutex.RLock()
+	defer registry.mutex.RUnlock()
+	if colors, ok := registry.themes[name]; ok {
+		return colors, nil
+	}
+	return nil, goerr.New("unknown theme", goerr.V("theme", name), goerr.V("available", registeredNames(registry.themes)))
+}
+
+func registeredNames[T any](m map[string]T) string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// resolvedOptions is Options validated and converted to options.
+type resolvedOptions struct {
+	options []Option
+	level   *slog.Level
+}
+
+// resolve validates the fields and converts them. Unlike WithTemplate, an invalid template is returned as an error.
+func (x Options) resolve() (*resolvedOptions, error) {
+	var r resolvedOptions
+
+	if x.Level != "" {
+		var level slog.Level
+		if err := level.UnmarshalText([]byte(x.Level)); err != nil {
+			return nil, goerr.Wrap(err, "invalid level", goerr.V("level", x.Level))
+		}
+		r.level = &level
+	}
+
+	if x.TimeFormat != "" {
+		r.options = append(r.options, WithTimeFmt(x.TimeFormat))
+	}
+	if x.Color != nil {
+		r.options = append(r.options, WithColor(*x.Color))
+	}
+	if x.Source != nil {
+		r.options = append(r.options, WithSource(*x.Source))
+	}
+
+	if x.Template != "" {
+		text, ok := templateNames[x.Template]
+		if !ok {
+			if !strings.Contains(x.Template, "{{") {
+				return nil, goerr.New("unknown template", goerr.V("template", x.Template))
+			}
+			text = x.Template
+		}
+		tmpl, err := template.New("options").Parse(text)
+		if err != nil {
+			return nil, goerr.Wrap(err, "failed to parse template")
+		}
+		if err := dryRunTemplate(tmpl); err != nil {
+			return nil, goerr.Wrap(err, "failed to execute template")
+		}
+		r.options = append(r.options, WithTemplate(tmpl))
+	}
+
+	if x.Printer != "" {
+		printer, err := lookupPrinter(x.Printer)
+		if err != nil {
+			return nil, err
+		}
+		r.options = append(r.options, WithPrinter(printer))
+	}
+
+	if x.Theme != "" {
+		colors, err := lookupTheme(x.Theme)
+		if err != nil {
+			return nil, err
+		}
+		r.options = append(r.options, WithColorMap(colors))
+	}
+
+	return &r, nil
+}
+
+// apply applies the options to cfg. If the level of cfg is a LevelController, the level is changed by the controller and announced with by.
+func (x *resolvedOptions) apply(cfg *config, by string) {
+	for _, opt := range x.options {
+		opt(cfg)
+	}
+	if x.level != nil {
+		if ctrl, ok := cfg.level.(*LevelController); ok {
+			ctrl.set(*x.level, by)
+		} else {
+			cfg.level = *x.level
+		}
+	}
+}
+
+// NewFromOptions creates a new handler configured by opts. options such as WithWriter are applied first and opts overrides them. If the level of options is a LevelController, Level of opts changes the level of the controller. It returns an error if opts has an invalid value.
+func NewFromOptions(opts Options, options ...Option) (*Handler, error) {
+	r, err := opts.resolve()
+	if err != nil {
+		return nil, err
+	}
+
+	options = append(options[:len(options):len(options)], func(cfg *config) {
+		r.apply(cfg, "api")
+	})
+	return New(options...), nil
+}
@@ -0,0 +1,123 @@
+package clog_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/m-mizutani/clog"
+	"github.com/m-mizutani/gt"
+)
+
+func TestOptionsJSON(t *testing.T) {
+	enabled := true
+	opts := clog.Options{
+		Level:    "debug",
+		Color:    &enabled,
+		Template: "standard",
+		Printer:  "indent",
+		Theme:    "light",
+	}
+
+	raw := gt.R1(json.Marshal(opts)).NoError(t)
+	gt.S(t, string(raw)).Equal(`{"level":"debug","color":true,"template":"standard","printer":"indent","theme":"light"}`)
+
+	var decoded clog.Options
+	gt.NoError(t, json.Unmarshal(raw, &decoded))
+	gt.V(t, decoded).Equal(opts)
+}
+
+func TestNewFromOptions(t *testing.T) {
+	disabled := false
+	var buf bytes.Buffer
+	h := gt.R1(clog.NewFromOptions(clog.Options{
+		Level:    "debug",
+		Color:    &disabled,
+		Template: "{{.Level}}: {{.Message}} ",
+		Printer:  "indent",
+	}, clog.WithWriter(&buf), clog.WithLevel(slog.LevelError))).NoError(t)
+
+	slog.New(h).Debug("hello", "user", "alice")
+	gt.S(t, buf.String()).Equal("DEBUG: hello \nuser: \"alice\"\n")
+}
+
+func TestNewFromOptionsInvalid(t *testing.T) {
+	for _, opts := range []clog.Options{
+		{Level: "verbose"},
+		{Template: "plain"},
+		{Template: "{{.Level"},
+		{Template: "{{.Unknown}}"},
+		{Printer: "fancy"},
+		{Theme: "neon"},
+	} {
+		_, err := clog.NewFromOptions(opts)
+		gt.Error(t, err)
+	}
+}
+
+func TestNewFromOptionsLevelController(t *testing.T) {
+	ctrl := clog.NewLevelController(slog.LevelInfo)
+	h := gt.R1(clog.NewFromOptions(clog.Options{Level: "warn"}, clog.WithLevel(ctrl))).NoError(t)
+
+	gt.V(t, ctrl.Level()).Equal(slog.LevelWarn)
+	gt.B(t, h.Enabled(t.Context(), slog.LevelInfo)).False()
+	ctrl.Set(slog.LevelDebug)
+	gt.B(t, h.Enabled(t.Context(), slog.LevelDebug)).True()
+}
+
+type keyPrinter struct {
+	w   io.Writer
+	ctx clog.PrinterContext
+}
+
+func (x *keyPrinter) Print(groups []string, attr slog.Attr) {
+	if attr.Value.Kind() == slog.KindGroup {
+		return
+	}
+	attr = x.ctx.ReplaceAttr(groups, attr)
+	key := strings.Join(append(groups, attr.Key), ".")
+	if x.ctx.ColorEnabled() {
+		key = x.ctx.Colors().AttrKey.Sprint(key)
+	}
+	fmt.Fprintf(x.w, "<%s> ", key)
+}
+
+func TestRegisterPrinter(t *testing.T) {
+	clog.RegisterPrinter("keys", func(w io.Writer, ctx clog.PrinterContext) clog.AttrPrinter {
+		return &keyPrinter{w: w, ctx: ctx}
+	})
+
+	disabled := false
+	var buf bytes.Buffer
+	h := gt.R1(clog.NewFromOptions(clog.Options{
+		Color:    &disabled,
+		Template: "standard",
+		Printer:  "keys",
+	}, clog.WithWriter(&buf))).NoError(t)
+
+	slog.New(h).Info("hello", "user", "alice", slog.Group("req", "path", "/"))
+	gt.S(t, buf.String()).Equal("INFO hello <user> <req.path> \n")
+}
+
+func TestRegisterTheme(t *testing.T) {
+	enableColorOutput(t)
+	clog.RegisterTheme("red", &clog.ColorMap{
+		LevelDefault: color.New(color.FgRed),
+	})
+
+	enabled := true
+	var buf bytes.Buffer
+	h := gt.R1(clog.NewFromOptions(clog.Options{
+		Color:    &enabled,
+		Template: "standard",
+		Theme:    "red",
+	}, clog.WithWriter(&buf))).NoError(t)
+
+	slog.New(h).Info("hello")
+	gt.S(t, buf.String()).Equal("\x1b[31mINFO\x1b[0m hello \n")
+}
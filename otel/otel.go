@@ -0,0 +1,43 @@
+// Package otel provides integration between clog and OpenTelemetry trace context. It only reads trace.SpanContext from context.Context and does not depend on any exporter.
+package otel
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/m-mizutani/clog"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// TraceIDKey is the attribute key of trace ID extracted by Extractor.
+	TraceIDKey = "trace_id"
+	// SpanIDKey is the attribute key of span ID extracted by Extractor.
+	SpanIDKey = "span_id"
+)
+
+// TraceContext returns trace and span IDs of the span context in ctx. Both are empty if ctx has no valid span context.
+func TraceContext(ctx context.Context) (traceID, spanID string) {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return "", ""
+	}
+	return sc.TraceID().String(), sc.SpanID().String()
+}
+
+// WithTrace returns a clog option that exposes trace and span IDs of the span context in ctx to the template as `.TraceID`, `.ShortTraceID` and `.SpanID`. Use it with clog.TemplateStandardWithTrace or a custom template.
+func WithTrace() clog.Option {
+	return clog.WithTraceContext(TraceContext)
+}
+
+// Extractor extracts trace and span IDs of the span context in ctx as attributes. It can be used with clog.WithContextExtractor to print the IDs as attributes.
+func Extractor(ctx context.Context) []slog.Attr {
+	traceID, spanID := TraceContext(ctx)
+	if traceID == "" {
+		return nil
+	}
+	return []slog.Attr{
+		slog.String(TraceIDKey, traceID),
+		slog.String(SpanIDKey, spanID),
+	}
+}
@@ -0,0 +1,92 @@
+package otel_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+	"text/template"
+
+	"github.com/fatih/color"
+	"github.com/m-mizutani/clog"
+	clogotel "github.com/m-mizutani/clog/otel"
+	"github.com/m-mizutani/gt"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func newSpanContext(t *testing.T, traceID, spanID string) context.Context {
+	tid, err := trace.TraceIDFromHex(traceID)
+	gt.NoError(t, err)
+	sid, err := trace.SpanIDFromHex(spanID)
+	gt.NoError(t, err)
+
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    tid,
+		SpanID:     sid,
+		TraceFlags: trace.FlagsSampled,
+	})
+	return trace.ContextWithSpanContext(context.Background(), sc)
+}
+
+func TestWithTrace(t *testing.T) {
+	var buf bytes.Buffer
+	tmpl := template.Must(template.New("trace").Parse(clog.TemplateStandardWithTrace))
+	logger := slog.New(clog.New(
+		clog.WithWriter(&buf),
+		clog.WithColor(false),
+		clog.WithTemplate(tmpl),
+		clogotel.WithTrace(),
+	))
+
+	ctx := newSpanContext(t, "4bf92f3577b34da6a3ce929d0e0e4736", "00f067aa0ba902b7")
+	logger.InfoContext(ctx, "traced")
+	gt.S(t, buf.String()).Contains("INFO [4bf92f35] traced")
+
+	buf.Reset()
+	logger.Info("not traced")
+	gt.S(t, buf.String()).
+		Contains("INFO not traced").
+		NotContains("[")
+}
+
+func TestWithTraceColor(t *testing.T) {
+	noColor := color.NoColor
+	color.NoColor = false
+	t.Cleanup(func() { color.NoColor = noColor })
+
+	var buf bytes.Buffer
+	tmpl := template.Must(template.New("trace").Parse(`{{.ShortTraceID}} {{.SpanID}} `))
+	logger := slog.New(clog.New(
+		clog.WithWriter(&buf),
+		clog.WithColor(true),
+		clog.WithTemplate(tmpl),
+		clogotel.WithTrace(),
+	))
+
+	ctx := newSpanContext(t, "4bf92f3577b34da6a3ce929d0e0e4736", "00f067aa0ba902b7")
+	logger.InfoContext(ctx, "first")
+	first := buf.String()
+	buf.Reset()
+	logger.InfoContext(ctx, "second")
+	second := buf.String()
+
+	gt.S(t, first).
+		Contains("4bf92f35\x1b[0m").
+		Contains(" 00f067aa0ba902b7 ")
+	gt.V(t, first).Equal(second)
+}
+
+func TestExtractor(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(clog.New(
+		clog.WithWriter(&buf),
+		clog.WithColor(false),
+		clog.WithContextExtractor(clogotel.Extractor),
+	))
+
+	ctx := newSpanContext(t, "4bf92f3577b34da6a3ce929d0e0e4736", "00f067aa0ba902b7")
+	logger.InfoContext(ctx, "traced")
+	gt.S(t, buf.String()).
+		Contains(`trace_id="4bf92f3577b34da6a3ce929d0e0e4736"`).
+		Contains(`span_id="00f067aa0ba902b7"`)
+}
@@ -7,6 +7,7 @@ import (
 
 	"log/slog"
 
+	"github.com/fatih/color"
 	"github.com/k0kubun/pp/v3"
 )
 
@@ -14,31 +15,101 @@ type AttrPrinter interface {
 	Print(groups []string, attr slog.Attr)
 }
 
-type basicPrinter struct {
-	w   io.Writer
+// PrinterContext gives an AttrPrinter the settings of the handler, so that printers outside of this package can respect them.
+type PrinterContext interface {
+	// ColorEnabled returns true if color is enabled for the output of the printer.
+	ColorEnabled() bool
+	// Colors returns the ColorMap of the handler.
+	Colors() *ColorMap
+	// ValueColor returns the color of the attribute value. A value of the key specified by WithHashColorKeys is colored by hash of the value. It returns nil if color is disabled.
+	ValueColor(groups []string, attr slog.Attr) *color.Color
+	// ReplaceAttr applies the function of WithReplaceAttr to the attribute. The attribute is returned as it is if the function is not set.
+	ReplaceAttr(groups []string, attr slog.Attr) slog.Attr
+	// Writer returns the destination of the record such as os.Stdout. It is to inspect the destination, and attributes should be written to the writer given to the printer.
+	Writer() io.Writer
+	// TerminalWidth returns the number of columns of the destination. It returns 0 if the destination is not a terminal.
+	TerminalWidth() int
+}
+
+// RecordPrinter is an optional interface of AttrPrinter to be notified of the beginning and the end of a record. BeginRecord is called before the first attribute and EndRecord after the last one, even if the record has no attributes.
+type RecordPrinter interface {
+	BeginRecord()
+	EndRecord()
+}
+
+// GroupPrinter is an optional interface of AttrPrinter to be notified of groups. EnterGroup is called before attributes of a group, including groups of WithGroup, and ExitGroup after them. Empty groups are not notified. Print of a GroupPrinter receives only attributes that are not groups, and other printers receive group attributes by Print before their attributes.
+type GroupPrinter interface {
+	EnterGroup(name string)
+	ExitGroup(name string)
+}
+
+// printerContext implements PrinterContext by the config.
+type printerContext struct {
 	cfg *config
+	dst io.Writer
 }
 
-func newBasicPrinter(w io.Writer, cfg *config) basicPrinter {
-	return basicPrinter{
-		w:   w,
-		cfg: cfg,
+func (x *printerContext) ColorEnabled() bool {
+	return x.cfg.enableColor
+}
+
+func (x *printerContext) Colors() *ColorMap {
+	return x.cfg.colors
+}
+
+func (x *printerContext) ValueColor(groups []string, attr slog.Attr) *color.Color {
+	if !x.cfg.enableColor {
+		return nil
+	}
+	if matchHashKey(x.cfg.hashColorKeys, groups, attr.Key) {
+		return x.cfg.colors.hashColor(attr.Value.String())
 	}
+	return x.cfg.colors.AttrValue
 }
 
-func (x *basicPrinter) Enter(group string) {
+func (x *printerContext) ReplaceAttr(groups []string, attr slog.Attr) slog.Attr {
+	if x.cfg.replaceAttr == nil {
+		return attr
+	}
+	return x.cfg.replaceAttr(groups, attr)
 }
 
-func (x *basicPrinter) Exit(group string) {
+func (x *printerContext) Writer() io.Writer {
+	return x.dst
+}
+
+func (x *printerContext) TerminalWidth() int {
+	f, ok := x.dst.(interface{ Fd() uintptr })
+	if !ok || !isTerminal(x.dst) {
+		return 0
+	}
+	return terminalWidth(f.Fd())
+}
+
+type basicPrinter struct {
+	w   io.Writer
+	ctx PrinterContext
+}
+
+func newBasicPrinter(w io.Writer, ctx PrinterContext) basicPrinter {
+	return basicPrinter{
+		w:   w,
+		ctx: ctx,
+	}
 }
 
-func (x *basicPrinter) Defer() {
+// attrKeyColor returns the color for the attribute key. It returns nil if color is disabled.
+func (x *basicPrinter) attrKeyColor() *color.Color {
+	if !x.ctx.ColorEnabled() {
+		return nil
+	}
+	return x.ctx.Colors().AttrKey
 }
 
 // LinearPrinter is a printer that prints attributes in a linear format.
-func LinearPrinter(w io.Writer, cfg *config) AttrPrinter {
+func LinearPrinter(w io.Writer, ctx PrinterContext) AttrPrinter {
 	return &linearPrinter{
-		basicPrinter: newBasicPrinter(w, cfg),
+		basicPrinter: newBasicPrinter(w, ctx),
 	}
 }
 
@@ -46,29 +117,32 @@ type linearPrinter struct {
 	basicPrinter
 }
 
+// LinearKey returns the key of the attribute in groups printed by LinearPrinter, e.g. "req.path".
+func LinearKey(groups []string, key string) string {
+	if len(groups) == 0 {
+		return key
+	}
+	return strings.Join(groups, ".") + "." + key
+}
+
 func (x *linearPrinter) Print(groups []string, attr slog.Attr) {
 	if attr.Value.Kind() == slog.KindGroup {
 		return
 	}
 
-	var keyPrefix string
-	if len(groups) > 0 {
-		keyPrefix = strings.Join(groups, ".") + "."
-	}
-
-	key := keyPrefix + attr.Key
+	key := LinearKey(groups, attr.Key)
 
 	p := fmt.Fprint
-	if x.cfg.enableColor && x.cfg.colors.AttrKey != nil {
-		p = x.cfg.colors.AttrKey.Fprint
+	if c := x.attrKeyColor(); c != nil {
+		p = c.Fprint
 	}
 	_, _ = p(x.w, key)
 
 	p = fmt.Fprint
 	_, _ = p(x.w, "=")
 
-	if x.cfg.enableColor && x.cfg.colors.AttrValue != nil {
-		p = x.cfg.colors.AttrValue.Fprint
+	if c := x.ctx.ValueColor(groups, attr); c != nil {
+		p = c.Fprint
 	}
 
 	value := valueToString(attr.Value)
@@ -79,20 +153,27 @@ func (x *linearPrinter) Print(groups []string, attr slog.Attr) {
 }
 
 // PrettyPrinter is a printer that prints attributes in a pretty format.
-func PrettyPrinter(w io.Writer, cfg *config) AttrPrinter {
+func PrettyPrinter(w io.Writer, ctx PrinterContext) AttrPrinter {
 	p := &prettyPrinter{
 		printer:      pp.New(),
-		basicPrinter: newBasicPrinter(w, cfg),
+		basicPrinter: newBasicPrinter(w, ctx),
 	}
-	p.printer.SetColoringEnabled(cfg.enableColor)
+	p.printer.SetColoringEnabled(ctx.ColorEnabled())
 	return p
 }
 
 type prettyPrinter struct {
 	printer *pp.PrettyPrinter
+	plain   *pp.PrettyPrinter
 	basicPrinter
 }
 
+// EnterGroup implements GroupPrinter. Attributes of groups are printed with keys prefixed by group names.
+func (x *prettyPrinter) EnterGroup(name string) {}
+
+// ExitGroup implements GroupPrinter.
+func (x *prettyPrinter) ExitGroup(name string) {}
+
 func (x *prettyPrinter) Print(groups []string, attr slog.Attr) {
 	var keyPrefix string
 	if len(groups) > 0 {
@@ -103,48 +184,62 @@ func (x *prettyPrinter) Print(groups []string, attr slog.Attr) {
 	p := fmt.Fprint
 	_, _ = p(x.w, "\n")
 
-	if x.cfg.enableColor && x.cfg.colors.AttrKey != nil {
-		p = x.cfg.colors.AttrKey.Fprint
+	if c := x.attrKeyColor(); c != nil {
+		p = c.Fprint
 	}
 	_, _ = p(x.w, key)
 
 	p = fmt.Fprint
 	_, _ = p(x.w, " => ")
+
+	// pp colors values by type, but a value with its own color such as a hash color is printed in the color
+	if c := x.ctx.ValueColor(groups, attr); c != nil && c != x.ctx.Colors().AttrValue {
+		if x.plain == nil {
+			x.plain = pp.New()
+			x.plain.SetColoringEnabled(false)
+		}
+		_, _ = c.Fprint(x.w, x.plain.Sprint(attr.Value.Any()))
+		return
+	}
 	_, _ = x.printer.Fprint(x.w, attr.Value.Any())
 }
 
 // IndentPrinter is a printer that prints attributes in a indented format.
-func IndentPrinter(w io.Writer, cfg *config) AttrPrinter {
+func IndentPrinter(w io.Writer, ctx PrinterContext) AttrPrinter {
 	return &indentPrinter{
-		basicPrinter: newBasicPrinter(w, cfg),
+		basicPrinter: newBasicPrinter(w, ctx),
 	}
 }
 
 type indentPrinter struct {
 	basicPrinter
+	depth int
 }
 
-func (x *indentPrinter) Print(groups []string, attr slog.Attr) {
-	if attr.Value.Kind() == slog.KindGroup {
-		indent := strings.Repeat("  ", len(groups))
-		_, _ = fmt.Fprintf(x.w, "\n%s%s:", indent, attr.Key)
-		return
-	}
+// EnterGroup implements GroupPrinter. It prints the group name as a header of the attributes.
+func (x *indentPrinter) EnterGroup(name string) {
+	_, _ = fmt.Fprintf(x.w, "\n%s%s:", strings.Repeat("  ", x.depth), name)
+	x.depth++
+}
+
+// ExitGroup implements GroupPrinter.
+func (x *indentPrinter) ExitGroup(name string) {
+	x.depth--
+}
 
-	indent := strings.Repeat("  ", len(groups))
+func (x *indentPrinter) Print(groups []string, attr slog.Attr) {
+	indent := strings.Repeat("  ", x.depth)
 
 	key := attr.Key
-	if x.cfg.enableColor && x.cfg.colors.AttrKey != nil {
-		key = x.cfg.colors.AttrKey.Sprint(key)
+	if c := x.attrKeyColor(); c != nil {
+		key = c.Sprint(key)
 	}
 
-	if x.cfg.replaceAttr != nil {
-		attr = x.cfg.replaceAttr(groups, attr)
-	}
+	attr = x.ctx.ReplaceAttr(groups, attr)
 
 	value := valueToString(attr.Value.Resolve())
-	if x.cfg.enableColor && x.cfg.colors.AttrValue != nil {
-		value = x.cfg.colors.AttrValue.Sprint(value)
+	if c := x.ctx.ValueColor(groups, attr); c != nil {
+		value = c.Sprint(value)
 	}
 
 	_, _ = fmt.Fprintf(x.w, "\n%s%s: %s", indent, key, value)
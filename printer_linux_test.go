@@ -0,0 +1,46 @@
+//go:build linux
+
+package clog_test
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/m-mizutani/clog"
+	"github.com/m-mizutani/gt"
+	"golang.org/x/sys/unix"
+)
+
+// widthPrinter prints the terminal width of the destination.
+type widthPrinter struct {
+	w   io.Writer
+	ctx clog.PrinterContext
+}
+
+func (x *widthPrinter) Print(groups []string, attr slog.Attr) {}
+
+func (x *widthPrinter) EndRecord() {
+	fmt.Fprintf(x.w, "width=%d ", x.ctx.TerminalWidth())
+}
+
+func (x *widthPrinter) BeginRecord() {}
+
+func TestPrinterContextTerminalWidth(t *testing.T) {
+	master, slave := openPty(t)
+	gt.NoError(t, unix.IoctlSetWinsize(int(slave.Fd()), unix.TIOCSWINSZ, &unix.Winsize{Row: 40, Col: 123}))
+
+	logger := slog.New(clog.New(
+		clog.WithWriter(slave),
+		clog.WithColor(false),
+		clog.WithTemplate(dedupTestTmpl),
+		clog.WithPrinter(func(w io.Writer, ctx clog.PrinterContext) clog.AttrPrinter {
+			return &widthPrinter{w: w, ctx: ctx}
+		}),
+	))
+	logger.Info("hello")
+
+	expected := "INFO hello width=123 \n"
+	gt.S(t, readN(t, master, len(expected))).Equal(expected)
+}
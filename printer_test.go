@@ -0,0 +1,145 @@
+package clog_test
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/m-mizutani/clog"
+	"github.com/m-mizutani/gt"
+)
+
+// eventPrinter records calls of AttrPrinter and its optional interfaces.
+type eventPrinter struct {
+	events *[]string
+}
+
+func (x *eventPrinter) Print(groups []string, attr slog.Attr) {
+	*x.events = append(*x.events, "print "+strings.Join(append(groups[:len(groups):len(groups)], attr.Key), "."))
+}
+
+func (x *eventPrinter) BeginRecord()           { *x.events = append(*x.events, "begin") }
+func (x *eventPrinter) EndRecord()             { *x.events = append(*x.events, "end") }
+func (x *eventPrinter) EnterGroup(name string) { *x.events = append(*x.events, "enter "+name) }
+func (x *eventPrinter) ExitGroup(name string)  { *x.events = append(*x.events, "exit "+name) }
+
+func TestPrinterEvents(t *testing.T) {
+	var events []string
+	logger := slog.New(clog.New(
+		clog.WithWriter(io.Discard),
+		clog.WithPrinter(func(w io.Writer, ctx clog.PrinterContext) clog.AttrPrinter {
+			return &eventPrinter{events: &events}
+		}),
+	))
+
+	logger.Info("hello", "a", 1, slog.Group("g", "b", 2, slog.Group("h", "c", 3)), "d", 4)
+	logger.Info("no attrs")
+
+	gt.A(t, events).Equal([]string{
+		"begin",
+		"print a",
+		"enter g",
+		"print g.b",
+		"enter h",
+		"print g.h.c",
+		"exit h",
+		"exit g",
+		"print d",
+		"end",
+		"begin",
+		"end",
+	})
+
+	t.Run("WithGroup", func(t *testing.T) {
+		events = nil
+		req := logger.With("svc", "api").WithGroup("req")
+		req.Info("with attrs", "path", "/")
+		req.Info("without attrs")
+		req.With("id", 1).WithGroup("body").Info("nested")
+
+		gt.A(t, events).Equal([]string{
+			"begin",
+			"print svc",
+			"enter req",
+			"print req.path",
+			"exit req",
+			"end",
+			"begin",
+			"print svc",
+			"end",
+			"begin",
+			"print svc",
+			"enter req",
+			"print req.id",
+			"exit req",
+			"end",
+		})
+	})
+
+	t.Run("empty groups", func(t *testing.T) {
+		events = nil
+		logger.Info("empty", slog.Group("g"), slog.Group("h", slog.Group("i")), slog.Group("j", slog.Group("k", "v", 1)))
+
+		gt.A(t, events).Equal([]string{
+			"begin",
+			"enter j",
+			"enter k",
+			"print j.k.v",
+			"exit k",
+			"exit j",
+			"end",
+		})
+	})
+}
+
+// attrOnlyPrinter is a printer without GroupPrinter.
+type attrOnlyPrinter struct {
+	events *[]string
+}
+
+func (x *attrOnlyPrinter) Print(groups []string, attr slog.Attr) {
+	*x.events = append(*x.events, "print "+strings.Join(append(groups[:len(groups):len(groups)], attr.Key), "."))
+}
+
+func TestPrinterWithoutGroupPrinter(t *testing.T) {
+	var events []string
+	logger := slog.New(clog.New(
+		clog.WithWriter(io.Discard),
+		clog.WithPrinter(func(w io.Writer, ctx clog.PrinterContext) clog.AttrPrinter {
+			return &attrOnlyPrinter{events: &events}
+		}),
+	))
+
+	logger.WithGroup("req").Info("hello", slog.Group("g", "b", 2), slog.Group("empty"))
+	gt.A(t, events).Equal([]string{
+		"print req.g.g",
+		"print req.g.b",
+	})
+}
+
+func TestPrinterContext(t *testing.T) {
+	var buf bytes.Buffer
+	var got clog.PrinterContext
+	logger := slog.New(clog.New(
+		clog.WithWriter(&buf),
+		clog.WithColor(false),
+		clog.WithTemplate(standardTmpl),
+		clog.WithReplaceAttr(func(groups []string, a slog.Attr) slog.Attr {
+			return slog.String(a.Key, "replaced")
+		}),
+		clog.WithPrinter(func(w io.Writer, ctx clog.PrinterContext) clog.AttrPrinter {
+			got = ctx
+			return clog.LinearPrinter(w, ctx)
+		}),
+	))
+	logger.Info("hello", "user", "alice")
+
+	gt.S(t, buf.String()).Equal("INFO hello user=\"replaced\" \n")
+	gt.V(t, got.Writer()).Equal(io.Writer(&buf))
+	gt.N(t, got.TerminalWidth()).Equal(0)
+	gt.B(t, got.ColorEnabled()).False()
+	gt.V(t, got.ValueColor(nil, slog.String("user", "alice"))).Nil()
+	gt.V(t, got.ReplaceAttr(nil, slog.Int("n", 1))).Equal(slog.String("n", "replaced"))
+}
@@ -0,0 +1,6 @@
+//go:build race
+
+package clog_test
+
+// raceEnabled is true if the race detector is enabled. Allocations are not stable with the race detector.
+const raceEnabled = true
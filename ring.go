@@ -0,0 +1,145 @@
+package clog
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+
+	"log/slog"
+)
+
+// Ring is an in-memory ring buffer that keeps the latest records below the level of the handler, e.g. DEBUG records while the console shows INFO and above. The records can be written later by Dump with the template and printer of the handler that received them. Ring is safe for concurrent use and can be shared by multiple handlers.
+type Ring struct {
+	level     slog.Leveler
+	dumpLevel *slog.Level
+
+	mutex   sync.Mutex
+	entries []ringEntry
+	next    int
+	count   int
+}
+
+type ringEntry struct {
+	handler *Handler
+	ctx     context.Context
+	record  slog.Record
+	timing  timing
+}
+
+// RingOption is a functional option for Ring.
+type RingOption func(*Ring)
+
+// WithRingLevel sets the minimum level of records captured by the ring buffer. The default is LevelDebug.
+func WithRingLevel(level slog.Leveler) RingOption {
+	return func(r *Ring) {
+		r.level = level
+	}
+}
+
+// WithDumpOn enables "flight recorder" mode. When a record of the level or above is handled, captured records are written before it to the same writers, and the ring buffer is cleared.
+func WithDumpOn(level slog.Level) RingOption {
+	return func(r *Ring) {
+		r.dumpLevel = &level
+	}
+}
+
+// NewRing creates a ring buffer that keeps the latest size records.
+func NewRing(size int, options ...RingOption) *Ring {
+	if size < 1 {
+		size = 1
+	}
+
+	r := &Ring{
+		level:   slog.LevelDebug,
+		entries: make([]ringEntry, size),
+	}
+	for _, opt := range options {
+		opt(r)
+	}
+	return r
+}
+
+// WithRing sets the ring buffer that captures records below the level of the handler.
+func WithRing(ring *Ring) Option {
+	return func(cfg *config) {
+		cfg.ring = ring
+	}
+}
+
+// Len returns the number of captured records.
+func (x *Ring) Len() int {
+	x.mutex.Lock()
+	defer x.mutex.Unlock()
+	return x.count
+}
+
+// Reset discards all captured records.
+func (x *Ring) Reset() {
+	_ = x.drain()
+}
+
+// Dump writes captured records to w from the oldest one. Each record is rendered by the handler that captured it, and color is enabled according to w. Captured records are kept after Dump.
+func (x *Ring) Dump(w io.Writer) error {
+	var errs []error
+	for _, entry := range x.snapshot() {
+		dst := entry.handler.cfg.newDestination(w)
+		if err := entry.handler.write(entry.ctx, entry.record, entry.timing, []destination{dst}); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (x *Ring) push(h *Handler, ctx context.Context, record slog.Record) {
+	if record.Level < x.level.Level() {
+		return
+	}
+
+	now := h.cfg.clock.Now()
+	x.mutex.Lock()
+	defer x.mutex.Unlock()
+
+	x.entries[x.next] = ringEntry{
+		handler: h,
+		ctx:     ctx,
+		record:  record.Clone(),
+		// Delta is not tracked for captured records because they are not printed in order with others
+		timing: timing{now: now, elapsed: now.Sub(h.cfg.startTime).Seconds()},
+	}
+	x.next = (x.next + 1) % len(x.entries)
+	if x.count < len(x.entries) {
+		x.count++
+	}
+}
+
+func (x *Ring) triggeredBy(level slog.Level) bool {
+	return x.dumpLevel != nil && *x.dumpLevel <= level
+}
+
+// snapshot returns captured records from the oldest one.
+func (x *Ring) snapshot() []ringEntry {
+	x.mutex.Lock()
+	defer x.mutex.Unlock()
+	return x.ordered()
+}
+
+// drain returns captured records from the oldest one and clears the ring buffer.
+func (x *Ring) drain() []ringEntry {
+	x.mutex.Lock()
+	defer x.mutex.Unlock()
+
+	entries := x.ordered()
+	clear(x.entries)
+	x.next, x.count = 0, 0
+	return entries
+}
+
+func (x *Ring) ordered() []ringEntry {
+	entries := make([]ringEntry, 0, x.count)
+	start := (x.next - x.count + len(x.entries)) % len(x.entries)
+	for i := 0; i < x.count; i++ {
+		entries = append(entries, x.entries[(start+i)%len(x.entries)])
+	}
+	return entries
+}
@@ -0,0 +1,82 @@
+package clog_test
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/m-mizutani/clog"
+	"github.com/m-mizutani/gt"
+)
+
+func TestRing(t *testing.T) {
+	var console bytes.Buffer
+	ring := clog.NewRing(2)
+	logger := slog.New(clog.New(
+		clog.WithColor(false),
+		clog.WithWriter(&console),
+		clog.WithRing(ring),
+	))
+
+	logger.With("worker", 1).Debug("debug 1")
+	logger.Debug("debug 2")
+	logger.WithGroup("g").Debug("debug 3", slog.String("foo", "bar"))
+	logger.Info("info")
+
+	gt.S(t, console.String()).
+		Contains("info").
+		NotContains("debug")
+	gt.N(t, ring.Len()).Equal(2)
+
+	var dump bytes.Buffer
+	gt.NoError(t, ring.Dump(&dump))
+	lines := strings.Split(strings.TrimSpace(dump.String()), "\n")
+	gt.A(t, lines).Length(2)
+	gt.S(t, lines[0]).Contains("DEBUG debug 2")
+	gt.S(t, lines[1]).Contains(`DEBUG debug 3 g.foo="bar"`)
+
+	// records are kept after Dump
+	gt.N(t, ring.Len()).Equal(2)
+	ring.Reset()
+	gt.N(t, ring.Len()).Equal(0)
+}
+
+func TestRingLevel(t *testing.T) {
+	ring := clog.NewRing(10, clog.WithRingLevel(slog.LevelInfo))
+	handler := clog.New(
+		clog.WithColor(false),
+		clog.WithWriter(&bytes.Buffer{}),
+		clog.WithLevel(slog.LevelWarn),
+		clog.WithRing(ring),
+	)
+	logger := slog.New(handler)
+
+	logger.Debug("debug")
+	logger.Info("info")
+	gt.N(t, ring.Len()).Equal(1)
+}
+
+func TestRingFlightRecorder(t *testing.T) {
+	var console bytes.Buffer
+	ring := clog.NewRing(10, clog.WithDumpOn(slog.LevelError))
+	logger := slog.New(clog.New(
+		clog.WithColor(false),
+		clog.WithWriter(&console),
+		clog.WithRing(ring),
+	))
+
+	logger.Debug("step 1")
+	logger.Debug("step 2")
+	logger.Warn("warning")
+	gt.S(t, console.String()).NotContains("step")
+
+	logger.Error("failed")
+	lines := strings.Split(strings.TrimSpace(console.String()), "\n")
+	gt.A(t, lines).Length(4)
+	gt.S(t, lines[0]).Contains("warning")
+	gt.S(t, lines[1]).Contains("step 1")
+	gt.S(t, lines[2]).Contains("step 2")
+	gt.S(t, lines[3]).Contains("failed")
+	gt.N(t, ring.Len()).Equal(0)
+}
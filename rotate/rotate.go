@@ -0,0 +1,405 @@
+// Package rotate provides an io.Writer that writes logs into a file and rotates it by size and/or daily. It implements clog.ColorSupporter, so clog disables color output for the file automatically.
+package rotate
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sync"
+	"time"
+
+	"github.com/m-mizutani/clog"
+	"github.com/m-mizutani/goerr/v2"
+)
+
+// Writer is an io.Writer that writes into a file and rotates it. Rotated files are renamed to "<path>.1", "<path>.2" and so on, where "<path>.1" is the newest. Writer is safe for concurrent use.
+type Writer struct {
+	path       string
+	maxSize    int64
+	daily      bool
+	maxBackups int
+	maxAge     time.Duration
+	compress   bool
+	now        func() time.Time
+
+	mutex    sync.Mutex
+	file     *os.File
+	size     int64
+	openedAt time.Time
+	// compressed receives the result of compression of the last rotated file running in background.
+	compressed chan error
+}
+
+var (
+	_ io.WriteCloser      = (*Writer)(nil)
+	_ clog.ColorSupporter = (*Writer)(nil)
+)
+
+// Option is a functional option for Writer.
+type Option func(*Writer)
+
+// WithMaxSize sets the maximum size of the file in bytes. The file is rotated before it exceeds the size. Zero or less disables rotation by size. The default is 0.
+func WithMaxSize(size int64) Option {
+	return func(w *Writer) {
+		w.maxSize = size
+	}
+}
+
+// WithDaily enables or disables daily rotation. When enabled, the file is rotated at the first write after the date changes. The default is false.
+func WithDaily(enable bool) Option {
+	return func(w *Writer) {
+		w.daily = enable
+	}
+}
+
+// WithMaxBackups sets the number of rotated files to keep. When n is 0, rotated files are removed immediately. The default is 5.
+func WithMaxBackups(n int) Option {
+	return func(w *Writer) {
+		w.maxBackups = n
+	}
+}
+
+// WithMaxAge sets the maximum age of rotated files. Rotated files that are older than the age are removed at rotation. Zero disables removal by age. The default is 0.
+func WithMaxAge(age time.Duration) Option {
+	return func(w *Writer) {
+		w.maxAge = age
+	}
+}
+
+// WithCompress enables or disables gzip compression of rotated files. Compressed files have ".gz" suffix. A rotated file is compressed in background not to block writes, and it is kept without compression if compression fails. The error is returned by the next rotation or Close. The default is false.
+func WithCompress(enable bool) Option {
+	return func(w *Writer) {
+		w.compress = enable
+	}
+}
+
+// WithClock sets the function to get current time. It is used for daily rotation and removal by age. The default is time.Now.
+func WithClock(now func() time.Time) Option {
+	return func(w *Writer) {
+		w.now = now
+	}
+}
+
+// New creates a Writer that writes into the file of path. The file is created if it does not exist, and new logs are appended to it.
+func New(path string, options ...Option) (*Writer, error) {
+	w := &Writer{
+		path:       path,
+		maxBackups: 5,
+		now:        time.Now,
+	}
+	for _, opt := range options {
+		opt(w)
+	}
+
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// SupportsColor implements clog.ColorSupporter. It always returns false because escape sequences are not wanted in log files.
+func (x *Writer) SupportsColor() bool {
+	return false
+}
+
+// Write implements io.Writer. The file is rotated before writing p if needed. If rotation fails, p is still written to the reopened file and the error of rotation is returned.
+func (x *Writer) Write(p []byte) (int, error) {
+	x.mutex.Lock()
+	defer x.mutex.Unlock()
+
+	if x.file == nil {
+		return 0, goerr.New("writer is already closed", goerr.V("path", x.path))
+	}
+
+	var rotateErr error
+	if x.shouldRotate(int64(len(p))) {
+		// p is written to the reopened file even if rotation fails not to lose the record
+		if rotateErr = x.rotate(); x.file == nil {
+			return 0, rotateErr
+		}
+	}
+
+	n, err := x.file.Write(p)
+	x.size += int64(n)
+	if err != nil {
+		return n, errors.Join(rotateErr, goerr.Wrap(err, "failed to write log file", goerr.V("path", x.path)))
+	}
+	return n, rotateErr
+}
+
+// Rotate rotates the file immediately.
+func (x *Writer) Rotate() error {
+	x.mutex.Lock()
+	defer x.mutex.Unlock()
+
+	if x.file == nil {
+		return goerr.New("writer is already closed", goerr.V("path", x.path))
+	}
+	return x.rotate()
+}
+
+// Reopen closes the file and opens the path again. It should be called after the file is moved by external tools such as logrotate.
+func (x *Writer) Reopen() error {
+	x.mutex.Lock()
+	defer x.mutex.Unlock()
+
+	if x.file == nil {
+		return goerr.New("writer is already closed", goerr.V("path", x.path))
+	}
+	if err := x.file.Close(); err != nil {
+		return goerr.Wrap(err, "failed to close log file", goerr.V("path", x.path))
+	}
+	x.file = nil
+	return x.open()
+}
+
+// ReopenOnSignal calls Reopen when one of signals is received, e.g. syscall.SIGHUP sent by logrotate. Errors of Reopen are ignored because there is no place to log them. The returned function stops watching signals.
+func (x *Writer) ReopenOnSignal(signals ...os.Signal) (stop func()) {
+	ch := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(ch, signals...)
+
+	go func() {
+		for {
+			select {
+			case <-ch:
+				_ = x.Reopen()
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(ch)
+			close(done)
+		})
+	}
+}
+
+// Close implements io.Closer. It waits for compression of the rotated file.
+func (x *Writer) Close() error {
+	x.mutex.Lock()
+	defer x.mutex.Unlock()
+
+	if x.file == nil {
+		return nil
+	}
+	err := x.file.Close()
+	x.file = nil
+	compressErr := x.waitCompression()
+	if err != nil {
+		return errors.Join(goerr.Wrap(err, "failed to close log file", goerr.V("path", x.path)), compressErr)
+	}
+	return compressErr
+}
+
+func (x *Writer) open() error {
+	f, err := os.OpenFile(x.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return goerr.Wrap(err, "failed to open log file", goerr.V("path", x.path))
+	}
+
+	stat, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return goerr.Wrap(err, "failed to stat log file", goerr.V("path", x.path))
+	}
+
+	x.file = f
+	x.size = stat.Size()
+	x.openedAt = x.now()
+	if x.size > 0 {
+		// the existing file may be written on another day
+		x.openedAt = stat.ModTime()
+	}
+	return nil
+}
+
+func (x *Writer) shouldRotate(n int64) bool {
+	if x.size == 0 {
+		return false
+	}
+	if x.maxSize > 0 && x.size+n > x.maxSize {
+		return true
+	}
+	if x.daily {
+		y1, m1, d1 := x.openedAt.Date()
+		y2, m2, d2 := x.now().Date()
+		if y1 != y2 || m1 != m2 || d1 != d2 {
+			return true
+		}
+	}
+	return false
+}
+
+// rotate moves the file to a backup and opens the path again. The path is reopened even if moving or removing backups fails so that logging continues, and the errors are returned together.
+func (x *Writer) rotate() error {
+	var errs []error
+	if err := x.file.Close(); err != nil {
+		errs = append(errs, goerr.Wrap(err, "failed to close log file", goerr.V("path", x.path)))
+	}
+	x.file = nil
+
+	// backups are not moved while the previous one is being compressed
+	if err := x.waitCompression(); err != nil {
+		errs = append(errs, err)
+	}
+	if err := x.removeExpired(); err != nil {
+		errs = append(errs, err)
+	}
+	if err := x.shiftBackups(); err != nil {
+		errs = append(errs, err)
+	}
+	if err := x.open(); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// backupPath returns the path of n-th rotated file, and the path with ".gz" suffix.
+func (x *Writer) backupPath(n int) (string, string) {
+	p := fmt.Sprintf("%s.%d", x.path, n)
+	return p, p + ".gz"
+}
+
+func (x *Writer) shiftBackups() error {
+	if x.maxBackups <= 0 {
+		if err := os.Remove(x.path); err != nil {
+			return goerr.Wrap(err, "failed to remove log file", goerr.V("path", x.path))
+		}
+		return nil
+	}
+
+	// remove the oldest backup, then shift others: <path>.N-1 -> <path>.N, ..., <path>.1 -> <path>.2
+	for _, p := range x.backupPaths(x.maxBackups) {
+		if err := removeIfExists(p); err != nil {
+			return err
+		}
+	}
+	for i := x.maxBackups - 1; i >= 1; i-- {
+		src, srcGz := x.backupPath(i)
+		dst, dstGz := x.backupPath(i + 1)
+		if err := renameIfExists(src, dst); err != nil {
+			return err
+		}
+		if err := renameIfExists(srcGz, dstGz); err != nil {
+			return err
+		}
+	}
+
+	newest, newestGz := x.backupPath(1)
+	if err := os.Rename(x.path, newest); err != nil {
+		return goerr.Wrap(err, "failed to rename log file", goerr.V("path", x.path))
+	}
+	if x.compress {
+		x.compressed = make(chan error, 1)
+		go func(done chan<- error) {
+			// the plain backup is kept if compression fails
+			done <- compressFile(newest, newestGz)
+		}(x.compressed)
+	}
+	return nil
+}
+
+// waitCompression waits for compression running in background and returns its error.
+func (x *Writer) waitCompression() error {
+	if x.compressed == nil {
+		return nil
+	}
+	err := <-x.compressed
+	x.compressed = nil
+	return err
+}
+
+func (x *Writer) backupPaths(n int) []string {
+	p, gz := x.backupPath(n)
+	return []string{p, gz}
+}
+
+func (x *Writer) removeExpired() error {
+	if x.maxAge <= 0 {
+		return nil
+	}
+
+	threshold := x.now().Add(-x.maxAge)
+	for i := 1; i <= x.maxBackups; i++ {
+		for _, p := range x.backupPaths(i) {
+			stat, err := os.Stat(p)
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			} else if err != nil {
+				return goerr.Wrap(err, "failed to stat rotated file", goerr.V("path", p))
+			}
+
+			if stat.ModTime().Before(threshold) {
+				if err := removeIfExists(p); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// compressFile compresses src to dst and removes src. If it fails, dst is removed and src is kept.
+func compressFile(src, dst string) (err error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return goerr.Wrap(err, "failed to open rotated file", goerr.V("path", src))
+	}
+	defer in.Close()
+
+	stat, err := in.Stat()
+	if err != nil {
+		return goerr.Wrap(err, "failed to stat rotated file", goerr.V("path", src))
+	}
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, stat.Mode())
+	if err != nil {
+		return goerr.Wrap(err, "failed to create compressed file", goerr.V("path", dst))
+	}
+	defer func() {
+		if err != nil {
+			_ = os.Remove(dst)
+		}
+	}()
+
+	gw := gzip.NewWriter(out)
+	if _, err := io.Copy(gw, in); err != nil {
+		_ = out.Close()
+		return goerr.Wrap(err, "failed to compress rotated file", goerr.V("path", src))
+	}
+	if err := gw.Close(); err != nil {
+		_ = out.Close()
+		return goerr.Wrap(err, "failed to compress rotated file", goerr.V("path", src))
+	}
+	if err := out.Close(); err != nil {
+		return goerr.Wrap(err, "failed to close compressed file", goerr.V("path", dst))
+	}
+
+	// keep modification time for removal by age
+	if err := os.Chtimes(dst, stat.ModTime(), stat.ModTime()); err != nil {
+		return goerr.Wrap(err, "failed to set time of compressed file", goerr.V("path", dst))
+	}
+	return removeIfExists(src)
+}
+
+func removeIfExists(path string) error {
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return goerr.Wrap(err, "failed to remove file", goerr.V("path", path))
+	}
+	return nil
+}
+
+func renameIfExists(src, dst string) error {
+	if err := os.Rename(src, dst); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return goerr.Wrap(err, "failed to rename file", goerr.V("src", src), goerr.V("dst", dst))
+	}
+	return nil
+}
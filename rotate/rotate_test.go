@@ -0,0 +1,160 @@
+package rotate_test
+
+import (
+	"compress/gzip"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/m-mizutani/clog"
+	"github.com/m-mizutani/clog/rotate"
+	"github.com/m-mizutani/gt"
+)
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	gt.NoError(t, err)
+	return string(data)
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func TestRotateBySize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	w := gt.R1(rotate.New(path,
+		rotate.WithMaxSize(10),
+		rotate.WithMaxBackups(2),
+	)).NoError(t)
+	t.Cleanup(func() { _ = w.Close() })
+
+	for _, line := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+		gt.R1(w.Write([]byte(line))).NoError(t)
+	}
+
+	gt.S(t, readFile(t, path)).Equal("fourth\n")
+	gt.S(t, readFile(t, path+".1")).Equal("third\n")
+	gt.S(t, readFile(t, path+".2")).Equal("second\n")
+	gt.B(t, fileExists(path+".3")).False()
+}
+
+func TestRotateDaily(t *testing.T) {
+	now := time.Date(2024, 1, 1, 23, 59, 0, 0, time.Local)
+	path := filepath.Join(t.TempDir(), "app.log")
+	w := gt.R1(rotate.New(path,
+		rotate.WithDaily(true),
+		rotate.WithClock(func() time.Time { return now }),
+	)).NoError(t)
+	t.Cleanup(func() { _ = w.Close() })
+
+	gt.R1(w.Write([]byte("day1\n"))).NoError(t)
+	gt.R1(w.Write([]byte("day1 again\n"))).NoError(t)
+	now = now.Add(2 * time.Minute)
+	gt.R1(w.Write([]byte("day2\n"))).NoError(t)
+
+	gt.S(t, readFile(t, path)).Equal("day2\n")
+	gt.S(t, readFile(t, path+".1")).Equal("day1\nday1 again\n")
+}
+
+func TestRotateCompress(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	w := gt.R1(rotate.New(path, rotate.WithCompress(true))).NoError(t)
+	t.Cleanup(func() { _ = w.Close() })
+
+	gt.R1(w.Write([]byte("old\n"))).NoError(t)
+	gt.NoError(t, w.Rotate())
+	gt.R1(w.Write([]byte("new\n"))).NoError(t)
+	gt.NoError(t, w.Rotate())
+	// Close waits for compression running in background
+	gt.NoError(t, w.Close())
+
+	gt.B(t, fileExists(path+".1")).False()
+	for file, expected := range map[string]string{
+		path + ".1.gz": "new\n",
+		path + ".2.gz": "old\n",
+	} {
+		f := gt.R1(os.Open(file)).NoError(t)
+		r := gt.R1(gzip.NewReader(f)).NoError(t)
+		gt.S(t, string(gt.R1(io.ReadAll(r)).NoError(t))).Equal(expected)
+		gt.NoError(t, f.Close())
+	}
+}
+
+func TestRotateFailure(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	w := gt.R1(rotate.New(path, rotate.WithMaxBackups(1), rotate.WithMaxSize(10))).NoError(t)
+	t.Cleanup(func() { _ = w.Close() })
+
+	// a non-empty directory at the backup path can't be removed
+	gt.NoError(t, os.MkdirAll(filepath.Join(path+".1", "dir"), 0755))
+
+	gt.R1(w.Write([]byte("first\n"))).NoError(t)
+	n, err := w.Write([]byte("second\n"))
+	gt.Error(t, err)
+	gt.N(t, n).Equal(7)
+	gt.Error(t, w.Rotate())
+
+	// the file is reopened and logging continues
+	gt.NoError(t, os.RemoveAll(path+".1"))
+	gt.R1(w.Write([]byte("third\n"))).NoError(t)
+	gt.S(t, readFile(t, path)).Equal("third\n")
+	gt.S(t, readFile(t, path+".1")).Equal("first\nsecond\n")
+}
+
+func TestRotateMaxAge(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	w := gt.R1(rotate.New(path, rotate.WithMaxAge(time.Hour))).NoError(t)
+	t.Cleanup(func() { _ = w.Close() })
+
+	gt.R1(w.Write([]byte("old\n"))).NoError(t)
+	gt.NoError(t, w.Rotate())
+	gt.B(t, fileExists(path+".1")).True()
+
+	old := time.Now().Add(-2 * time.Hour)
+	gt.NoError(t, os.Chtimes(path+".1", old, old))
+	gt.R1(w.Write([]byte("new\n"))).NoError(t)
+	gt.NoError(t, w.Rotate())
+
+	gt.S(t, readFile(t, path+".1")).Equal("new\n")
+	gt.B(t, fileExists(path+".2")).False()
+}
+
+func TestReopen(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	w := gt.R1(rotate.New(path)).NoError(t)
+	t.Cleanup(func() { _ = w.Close() })
+
+	gt.R1(w.Write([]byte("before\n"))).NoError(t)
+	// emulate logrotate moving the file
+	gt.NoError(t, os.Rename(path, filepath.Join(dir, "moved.log")))
+	gt.NoError(t, w.Reopen())
+	gt.R1(w.Write([]byte("after\n"))).NoError(t)
+
+	gt.S(t, readFile(t, path)).Equal("after\n")
+	gt.S(t, readFile(t, filepath.Join(dir, "moved.log"))).Equal("before\n")
+}
+
+func TestNoColorWithClog(t *testing.T) {
+	noColor := color.NoColor
+	color.NoColor = false
+	t.Cleanup(func() { color.NoColor = noColor })
+
+	path := filepath.Join(t.TempDir(), "app.log")
+	w := gt.R1(rotate.New(path)).NoError(t)
+	t.Cleanup(func() { _ = w.Close() })
+
+	logger := slog.New(clog.New(clog.WithWriter(w)))
+	logger.Info("hello, world!", slog.String("foo", "bar"))
+
+	gt.S(t, readFile(t, path)).
+		Contains(`INFO hello, world! foo="bar"`).
+		NotContains("\x1b[")
+}
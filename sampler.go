@@ -0,0 +1,186 @@
+package clog
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"log/slog"
+)
+
+// Sampler drops repetitive records. Records are grouped by level and message, and in each interval the first N records of a group are passed and then every Mth record is passed. When an interval of a group ends with suppressed records, a summary record like "suppressed 1532 similar records" is written with the next record, or by a timer if no record arrives. Summaries of the current interval are written by Handler.Flush, e.g. when a flood stops before the program exits. Sampler is safe for concurrent use and can be shared by multiple handlers.
+type Sampler struct {
+	first      int
+	thereafter int
+	interval   time.Duration
+
+	mutex      sync.Mutex
+	counters   map[samplingKey]*samplingCounter
+	lastSweep  time.Time
+	timer      *time.Timer
+	passed     uint64
+	suppressed uint64
+}
+
+type samplingKey struct {
+	level   slog.Level
+	message string
+}
+
+type samplingCounter struct {
+	start      time.Time
+	count      int
+	suppressed int
+}
+
+// samplingSummary is a summary of suppressed records of a group.
+type samplingSummary struct {
+	key        samplingKey
+	suppressed int
+}
+
+// NewSampler creates a Sampler that passes the first records of each level and message in every interval, and then passes every thereafter-th record. If thereafter is 0 or less, all records after the first ones are suppressed in the interval.
+func NewSampler(first, thereafter int, interval time.Duration) *Sampler {
+	return &Sampler{
+		first:      first,
+		thereafter: thereafter,
+		interval:   interval,
+		counters:   make(map[samplingKey]*samplingCounter),
+	}
+}
+
+// WithSampler sets the sampler of the handler. The sampler applies to records of the handler's level and above, and records captured by the ring buffer are not sampled.
+func WithSampler(sampler *Sampler) Option {
+	return func(cfg *config) {
+		cfg.sampler = sampler
+	}
+}
+
+// Passed returns the number of records passed by the sampler.
+func (x *Sampler) Passed() uint64 {
+	x.mutex.Lock()
+	defer x.mutex.Unlock()
+	return x.passed
+}
+
+// Suppressed returns the number of records suppressed by the sampler.
+func (x *Sampler) Suppressed() uint64 {
+	x.mutex.Lock()
+	defer x.mutex.Unlock()
+	return x.suppressed
+}
+
+// sample returns true if the record should be written. It also returns summaries of groups whose interval has ended with suppressed records. If the record is suppressed, the timer is started to write the summary by h when no record arrives.
+func (x *Sampler) sample(level slog.Level, message string, now time.Time, h *Handler) (bool, []samplingSummary) {
+	x.mutex.Lock()
+	defer x.mutex.Unlock()
+
+	summaries := x.sweep(now)
+
+	key := samplingKey{level: level, message: message}
+	counter, ok := x.counters[key]
+	if !ok || now.Sub(counter.start) >= x.interval {
+		if ok && counter.suppressed > 0 {
+			summaries = append(summaries, samplingSummary{key: key, suppressed: counter.suppressed})
+		}
+		counter = &samplingCounter{start: now}
+		x.counters[key] = counter
+	}
+
+	counter.count++
+	pass := counter.count <= x.first ||
+		(x.thereafter > 0 && (counter.count-x.first)%x.thereafter == 0)
+	if pass {
+		x.passed++
+	} else {
+		x.suppressed++
+		counter.suppressed++
+		x.startTimer(now, h)
+	}
+
+	return pass, summaries
+}
+
+// startTimer starts the timer that fires when the earliest interval of groups with suppressed records ends. It does nothing if the timer is already running.
+func (x *Sampler) startTimer(now time.Time, h *Handler) {
+	if x.timer != nil {
+		return
+	}
+
+	var end time.Time
+	for _, counter := range x.counters {
+		if counter.suppressed > 0 && (end.IsZero() || counter.start.Before(end)) {
+			end = counter.start
+		}
+	}
+	if end.IsZero() {
+		return
+	}
+	x.timer = time.AfterFunc(end.Add(x.interval).Sub(now), func() {
+		h.writeExpiredSummaries(x)
+	})
+}
+
+// expire returns summaries of groups whose interval has ended, and restarts the timer for the remaining groups. It is called by the timer.
+func (x *Sampler) expire(now time.Time, h *Handler) []samplingSummary {
+	x.mutex.Lock()
+	defer x.mutex.Unlock()
+
+	x.timer = nil
+	x.lastSweep = time.Time{}
+	summaries := x.sweep(now)
+	sortSummaries(summaries)
+	x.startTimer(now, h)
+	return summaries
+}
+
+// sweep removes groups whose interval has ended, and returns summaries of them. It runs at most once per interval to keep the cost of each record small.
+func (x *Sampler) sweep(now time.Time) []samplingSummary {
+	if now.Sub(x.lastSweep) < x.interval {
+		return nil
+	}
+	x.lastSweep = now
+
+	var summaries []samplingSummary
+	for key, counter := range x.counters {
+		if now.Sub(counter.start) < x.interval {
+			continue
+		}
+		if counter.suppressed > 0 {
+			summaries = append(summaries, samplingSummary{key: key, suppressed: counter.suppressed})
+		}
+		delete(x.counters, key)
+	}
+	return summaries
+}
+
+// flush returns summaries of all groups that have suppressed records, and resets their counts of suppressed records. Summaries are sorted by level and message.
+func (x *Sampler) flush() []samplingSummary {
+	x.mutex.Lock()
+	defer x.mutex.Unlock()
+
+	var summaries []samplingSummary
+	for key, counter := range x.counters {
+		if counter.suppressed > 0 {
+			summaries = append(summaries, samplingSummary{key: key, suppressed: counter.suppressed})
+			counter.suppressed = 0
+		}
+	}
+	sortSummaries(summaries)
+	return summaries
+}
+
+func sortSummaries(summaries []samplingSummary) {
+	slices.SortFunc(summaries, func(a, b samplingSummary) int {
+		return cmp.Or(cmp.Compare(a.key.level, b.key.level), cmp.Compare(a.key.message, b.key.message))
+	})
+}
+
+// record returns a summary record.
+func (x samplingSummary) record(now time.Time) slog.Record {
+	record := slog.NewRecord(now, x.key.level, fmt.Sprintf("suppressed %d similar records", x.suppressed), 0)
+	record.AddAttrs(slog.String("message", x.key.message))
+	return record
+}
@@ -0,0 +1,184 @@
+package clog_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"text/template"
+	"time"
+
+	"github.com/m-mizutani/clog"
+	"github.com/m-mizutani/gt"
+)
+
+// manualClock is a Clock that returns the time set by the test.
+type manualClock struct {
+	now time.Time
+}
+
+func (x *manualClock) Now() time.Time {
+	return x.now
+}
+
+func TestSampler(t *testing.T) {
+	var buf bytes.Buffer
+	clock := &manualClock{now: goldenTime}
+	sampler := clog.NewSampler(2, 3, time.Second)
+	logger := slog.New(clog.New(
+		clog.WithWriter(&buf),
+		clog.WithColor(false),
+		clog.WithClock(clock),
+		clog.WithSampler(sampler),
+		clog.WithTemplate(template.Must(template.New("sampler").Parse(`{{.Level}} {{.Message}} `))),
+	))
+
+	for i := 0; i < 10; i++ {
+		logger.Info("hot loop", slog.Int("i", i))
+	}
+	logger.Warn("hot loop")
+
+	// first 2 records and then every 3rd record are passed
+	gt.S(t, buf.String()).Equal(strings.Join([]string{
+		"INFO hot loop i=0 ",
+		"INFO hot loop i=1 ",
+		"INFO hot loop i=4 ",
+		"INFO hot loop i=7 ",
+		"WARN hot loop ",
+		"",
+	}, "\n"))
+	gt.N(t, sampler.Passed()).Equal(5)
+	gt.N(t, sampler.Suppressed()).Equal(6)
+
+	buf.Reset()
+	clock.now = clock.now.Add(time.Second)
+	logger.WithGroup("g").Info("hot loop", slog.Int("i", 10))
+	gt.S(t, buf.String()).Equal(strings.Join([]string{
+		`INFO suppressed 6 similar records message="hot loop" `,
+		`INFO hot loop g.i=10 `,
+		"",
+	}, "\n"))
+}
+
+func TestSamplerSweep(t *testing.T) {
+	var buf bytes.Buffer
+	clock := &manualClock{now: goldenTime}
+	sampler := clog.NewSampler(1, 0, time.Second)
+	logger := slog.New(clog.New(
+		clog.WithWriter(&buf),
+		clog.WithColor(false),
+		clog.WithClock(clock),
+		clog.WithSampler(sampler),
+		clog.WithTemplate(template.Must(template.New("sampler").Parse(`{{.Level}} {{.Message}} `))),
+	))
+
+	logger.Info("first")
+	logger.Info("first")
+	logger.Info("first")
+	clock.now = clock.now.Add(2 * time.Second)
+	logger.Info("second")
+
+	// summary of another message is written when its interval has ended
+	gt.S(t, buf.String()).Equal(strings.Join([]string{
+		`INFO first `,
+		`INFO suppressed 2 similar records message="first" `,
+		`INFO second `,
+		"",
+	}, "\n"))
+	gt.N(t, sampler.Suppressed()).Equal(2)
+}
+
+type requestIDKey struct{}
+
+func TestSamplerFlush(t *testing.T) {
+	var buf bytes.Buffer
+	clock := &manualClock{now: goldenTime}
+	h := clog.New(
+		clog.WithWriter(&buf),
+		clog.WithColor(false),
+		clog.WithClock(clock),
+		clog.WithSampler(clog.NewSampler(1, 0, time.Second)),
+		clog.WithTemplate(template.Must(template.New("sampler").Parse(`{{.Level}} {{.Message}} `))),
+		clog.WithContextExtractor(func(ctx context.Context) []slog.Attr {
+			if id, ok := ctx.Value(requestIDKey{}).(string); ok {
+				return []slog.Attr{slog.String("request_id", id)}
+			}
+			return nil
+		}),
+	)
+	logger := slog.New(h)
+	ctx := context.WithValue(context.Background(), requestIDKey{}, "r1")
+
+	for i := 0; i < 3; i++ {
+		logger.WarnContext(ctx, "flood")
+		logger.InfoContext(ctx, "flood")
+	}
+	// the flood stops, and summaries of the current interval are written by Flush
+	gt.NoError(t, h.Flush())
+	gt.NoError(t, h.Flush())
+
+	clock.now = clock.now.Add(2 * time.Second)
+	logger.Info("after")
+
+	gt.S(t, buf.String()).Equal(strings.Join([]string{
+		`WARN flood request_id="r1" `,
+		`INFO flood request_id="r1" `,
+		`INFO suppressed 2 similar records message="flood" `,
+		`WARN suppressed 2 similar records message="flood" `,
+		`INFO after `,
+		"",
+	}, "\n"))
+}
+
+// syncClock is a Clock that returns the time set by the test, and it can be read by timers.
+type syncClock struct {
+	mutex sync.Mutex
+	now   time.Time
+}
+
+func (x *syncClock) Now() time.Time {
+	x.mutex.Lock()
+	defer x.mutex.Unlock()
+	return x.now
+}
+
+func (x *syncClock) add(d time.Duration) {
+	x.mutex.Lock()
+	defer x.mutex.Unlock()
+	x.now = x.now.Add(d)
+}
+
+func TestSamplerTimer(t *testing.T) {
+	var buf syncBuffer
+	clock := &syncClock{now: goldenTime}
+	interval := 10 * time.Millisecond
+	logger := slog.New(clog.New(
+		clog.WithWriter(&buf),
+		clog.WithColor(false),
+		clog.WithClock(clock),
+		clog.WithSampler(clog.NewSampler(1, 0, interval)),
+		clog.WithTemplate(template.Must(template.New("sampler").Parse(`{{.Level}} {{.Message}} `))),
+	))
+
+	for i := 0; i < 3; i++ {
+		logger.Info("flood")
+	}
+
+	// the interval has not ended by the clock yet
+	time.Sleep(5 * interval)
+	gt.S(t, buf.String()).Equal("INFO flood \n")
+
+	// the summary is written without new records when the interval ends
+	clock.add(interval)
+	deadline := time.Now().Add(time.Second)
+	for !strings.Contains(buf.String(), "suppressed") && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	gt.S(t, buf.String()).Equal(strings.Join([]string{
+		`INFO flood `,
+		`INFO suppressed 2 similar records message="flood" `,
+		"",
+	}, "\n"))
+}
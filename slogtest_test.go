@@ -0,0 +1,62 @@
+package clog_test
+
+import (
+	"bufio"
+	"bytes"
+	"log/slog"
+	"testing"
+	"testing/slogtest"
+
+	"github.com/m-mizutani/clog"
+	"github.com/m-mizutani/clog/logfmt"
+	"github.com/m-mizutani/gt"
+)
+
+// recordToMap converts a record parsed by logfmt to the form of slogtest.
+func recordToMap(record slog.Record) map[string]any {
+	m := map[string]any{
+		slog.LevelKey:   record.Level,
+		slog.MessageKey: record.Message,
+	}
+	if !record.Time.IsZero() {
+		m[slog.TimeKey] = record.Time
+	}
+	record.Attrs(func(attr slog.Attr) bool {
+		m[attr.Key] = attrValueToAny(attr.Value)
+		return true
+	})
+	return m
+}
+
+func attrValueToAny(value slog.Value) any {
+	if value.Kind() != slog.KindGroup {
+		return value.Any()
+	}
+	m := map[string]any{}
+	for _, attr := range value.Group() {
+		m[attr.Key] = attrValueToAny(attr.Value)
+	}
+	return m
+}
+
+func TestSlogtest(t *testing.T) {
+	var buf bytes.Buffer
+	h := clog.New(
+		clog.WithWriter(&buf),
+		clog.WithColor(false),
+		clog.WithLevel(slog.LevelDebug),
+	)
+
+	results := func() []map[string]any {
+		var ms []map[string]any
+		scanner := bufio.NewScanner(&buf)
+		for scanner.Scan() {
+			record := gt.R1(logfmt.Parse(scanner.Text())).NoError(t)
+			ms = append(ms, recordToMap(record))
+		}
+		gt.NoError(t, scanner.Err())
+		return ms
+	}
+
+	gt.NoError(t, slogtest.TestHandler(h, results))
+}
@@ -0,0 +1,117 @@
+package clog
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+
+	"log/slog"
+)
+
+// ProgressKey is the attribute key of Progress.
+const ProgressKey = "progress"
+
+// ProgressValue is a value of progress attribute created by Progress.
+type ProgressValue struct {
+	Current int64
+	Total   int64
+}
+
+// String returns progress like "3/10 (30%)".
+func (x ProgressValue) String() string {
+	if x.Total <= 0 {
+		return fmt.Sprintf("%d", x.Current)
+	}
+	return fmt.Sprintf("%d/%d (%d%%)", x.Current, x.Total, x.Current*100/x.Total)
+}
+
+// done returns true if the progress is completed.
+func (x ProgressValue) done() bool {
+	return x.Total > 0 && x.Current >= x.Total
+}
+
+// Progress returns an attribute that tags the record as progress. With WithStatusLine, a record that has the attribute updates the status line at the bottom of the terminal instead of being appended. The status line is finished as a normal line when current reaches total.
+func Progress(current, total int64) slog.Attr {
+	return slog.Any(ProgressKey, ProgressValue{Current: current, Total: total})
+}
+
+// WithStatusLine enables the status line mode. Records tagged by Progress update a single status line at the bottom, and other records keep scrolling above it. The mode uses ANSI cursor control only when all writers of the record are terminals, and progress records are written as normal lines otherwise. The status line should fit in one line, so LinearPrinter is recommended.
+func WithStatusLine(enable bool) Option {
+	return func(cfg *config) {
+		cfg.statusLine = enable
+	}
+}
+
+// statusState is the current status line. It is shared by handlers derived from the same handler and accessed under the lock of the handler.
+type statusState struct {
+	lines map[bool][]byte
+	dests []destination
+}
+
+// clearLine moves the cursor to the beginning of the line and clears the line.
+const clearLine = "\r\x1b[2K"
+
+// lineOn returns the status line drawn on the terminal of dst.
+func (x *statusState) lineOn(dst destination) ([]byte, bool) {
+	if x.lines == nil || !dst.terminal {
+		return nil, false
+	}
+
+	fd, ok := dst.w.(interface{ Fd() uintptr })
+	if !ok {
+		return nil, false
+	}
+	for _, sd := range x.dests {
+		if sf, ok := sd.w.(interface{ Fd() uintptr }); ok && sf.Fd() == fd.Fd() {
+			return x.lines[sd.color], true
+		}
+	}
+	return nil, false
+}
+
+// findProgress returns the progress attribute of the record.
+func findProgress(record slog.Record) (ProgressValue, bool) {
+	var progress ProgressValue
+	var found bool
+	record.Attrs(func(attr slog.Attr) bool {
+		if v, ok := attr.Value.Any().(ProgressValue); ok && attr.Key == ProgressKey {
+			progress, found = v, true
+			return false
+		}
+		return true
+	})
+	return progress, found
+}
+
+// writeStatus writes the progress record as the status line.
+func (x *Handler) writeStatus(ctx context.Context, record slog.Record, progress ProgressValue, t timing, dests []destination) error {
+	lines, err := x.resolve(ctx, record, t, x.cfg).renderLines(x.cfg, dests)
+	if err != nil {
+		return err
+	}
+	for color, line := range lines {
+		lines[color] = bytes.TrimRight(line, "\n")
+	}
+
+	x.mutex.Lock()
+	defer x.mutex.Unlock()
+
+	var errs []error
+	for _, dst := range dests {
+		b := append([]byte(clearLine), lines[dst.color]...)
+		if progress.done() {
+			b = append(b, '\n')
+		}
+		if _, err := dst.w.Write(b); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if progress.done() {
+		x.cfg.status.lines, x.cfg.status.dests = nil, nil
+	} else {
+		x.cfg.status.lines, x.cfg.status.dests = lines, dests
+	}
+	return errors.Join(errs...)
+}
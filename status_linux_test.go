@@ -0,0 +1,70 @@
+//go:build linux
+
+package clog_test
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"testing"
+
+	"github.com/m-mizutani/clog"
+	"github.com/m-mizutani/gt"
+	"golang.org/x/sys/unix"
+)
+
+// openPty opens a pseudo terminal and returns the master and the slave.
+func openPty(t *testing.T) (*os.File, *os.File) {
+	t.Helper()
+	master, err := os.OpenFile("/dev/ptmx", os.O_RDWR, 0)
+	if err != nil {
+		t.Skip("pty is not available:", err)
+	}
+	t.Cleanup(func() { _ = master.Close() })
+
+	n := gt.R1(unix.IoctlGetInt(int(master.Fd()), unix.TIOCGPTN)).NoError(t)
+	gt.NoError(t, unix.IoctlSetPointerInt(int(master.Fd()), unix.TIOCSPTLCK, 0))
+
+	slave, err := os.OpenFile(fmt.Sprintf("/dev/pts/%d", n), os.O_RDWR, 0)
+	if err != nil {
+		t.Skip("pty is not available:", err)
+	}
+	t.Cleanup(func() { _ = slave.Close() })
+
+	// disable output processing such as "\n" to "\r\n"
+	termios := gt.R1(unix.IoctlGetTermios(int(slave.Fd()), unix.TCGETS)).NoError(t)
+	termios.Oflag &^= unix.OPOST
+	gt.NoError(t, unix.IoctlSetTermios(int(slave.Fd()), unix.TCSETS, termios))
+
+	return master, slave
+}
+
+// readN reads n bytes written to the pty.
+func readN(t *testing.T, master *os.File, n int) string {
+	t.Helper()
+	buf := make([]byte, n)
+	gt.R1(io.ReadFull(master, buf)).NoError(t)
+	return string(buf)
+}
+
+func TestStatusLineTerminal(t *testing.T) {
+	master, slave := openPty(t)
+	logger := slog.New(clog.New(
+		clog.WithWriter(slave),
+		clog.WithColor(false),
+		clog.WithTemplate(dedupTestTmpl),
+		clog.WithStatusLine(true),
+	))
+
+	logger.Info("downloading", clog.Progress(1, 2))
+	logger.Info("found", slog.String("file", "a.txt"))
+	logger.Info("downloading", clog.Progress(2, 2))
+	logger.Info("done")
+
+	expected := "\r\x1b[2KINFO downloading progress=1/2 (50%) " +
+		"\r\x1b[2KINFO found file=\"a.txt\" \nINFO downloading progress=1/2 (50%) " +
+		"\r\x1b[2KINFO downloading progress=2/2 (100%) \n" +
+		"INFO done \n"
+	gt.S(t, readN(t, master, len(expected))).Equal(expected)
+}
@@ -0,0 +1,35 @@
+package clog_test
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+
+	"github.com/m-mizutani/clog"
+	"github.com/m-mizutani/gt"
+)
+
+func TestProgressValue(t *testing.T) {
+	gt.S(t, clog.Progress(3, 10).Value.String()).Equal("3/10 (30%)")
+	gt.S(t, clog.Progress(7, 0).Value.String()).Equal("7")
+	gt.S(t, clog.Progress(3, 10).Key).Equal(clog.ProgressKey)
+}
+
+func TestStatusLineNotTerminal(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(clog.New(
+		clog.WithWriter(&buf),
+		clog.WithColor(false),
+		clog.WithTemplate(dedupTestTmpl),
+		clog.WithStatusLine(true),
+	))
+
+	logger.Info("downloading", clog.Progress(1, 2))
+	logger.Info("found", slog.String("file", "a.txt"))
+	logger.Info("downloading", clog.Progress(2, 2))
+
+	gt.S(t, buf.String()).
+		Equal("INFO downloading progress=1/2 (50%) \n" +
+			"INFO found file=\"a.txt\" \n" +
+			"INFO downloading progress=2/2 (100%) \n")
+}
@@ -0,0 +1,14 @@
+//go:build unix
+
+package clog
+
+import "golang.org/x/sys/unix"
+
+// terminalWidth returns the number of columns of the terminal of fd.
+func terminalWidth(fd uintptr) int {
+	ws, err := unix.IoctlGetWinsize(int(fd), unix.TIOCGWINSZ)
+	if err != nil {
+		return 0
+	}
+	return int(ws.Col)
+}
@@ -0,0 +1,8 @@
+//go:build !unix && !windows
+
+package clog
+
+// terminalWidth returns 0 because terminal size is not available on the platform.
+func terminalWidth(fd uintptr) int {
+	return 0
+}
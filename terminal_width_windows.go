@@ -0,0 +1,14 @@
+//go:build windows
+
+package clog
+
+import "golang.org/x/sys/windows"
+
+// terminalWidth returns the number of columns of the console window of fd.
+func terminalWidth(fd uintptr) int {
+	var info windows.ConsoleScreenBufferInfo
+	if err := windows.GetConsoleScreenBufferInfo(windows.Handle(fd), &info); err != nil {
+		return 0
+	}
+	return int(info.Window.Right - info.Window.Left + 1)
+}
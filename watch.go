@@ -0,0 +1,165 @@
+package clog
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/m-mizutani/goerr/v2"
+)
+
+// Watcher reloads the configuration file of the handler created by Watch.
+type Watcher struct {
+	path    string
+	handler *Handler
+	base    *config
+
+	mutex   sync.Mutex
+	modTime time.Time
+	size    int64
+
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+}
+
+// Watch creates a handler configured by the JSON file of Options at path, and polls the file every interval to reload it. interval must be positive. All fields of the file are optional:
+//
+//	{
+//	  "level": "debug",
+//	  "template": "elapsed",
+//	  "printer": "pretty",
+//	  "theme": "light",
+//	  "color": true,
+//	  "source": true,
+//	  "time_format": "15:04:05"
+//	}
+//
+// options are applied first and the file overrides them. If the level of options is a LevelController, "level" changes the level of the controller.
+//
+// When the file changes, the configuration of the handler and handlers derived from it is swapped atomically. Records being handled are written with the previous configuration, and states such as WithDedup, WithRing and WithSampler are kept. A reload is announced by a log line, and if the file becomes invalid, the error is logged and the previous configuration is kept. The file is polled by modification time and size so that it works without file notification of the OS.
+func Watch(path string, interval time.Duration, options ...Option) (*Watcher, error) {
+	if interval <= 0 {
+		return nil, goerr.New("interval must be positive", goerr.V("interval", interval))
+	}
+
+	h := New(options...)
+	h.live = &atomic.Pointer[config]{}
+	h.live.Store(h.cfg)
+	h.latest = &atomic.Pointer[Handler]{}
+
+	x := &Watcher{
+		path:    path,
+		handler: h,
+		base:    h.cfg,
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	if err := x.reload("api"); err != nil {
+		return nil, err
+	}
+
+	go x.poll(interval)
+	return x, nil
+}
+
+// Handler returns the handler configured by the file.
+func (x *Watcher) Handler() *Handler {
+	return x.handler
+}
+
+// Reload reads the file and swaps the configuration immediately. Nothing is changed if the file is invalid.
+func (x *Watcher) Reload() error {
+	return x.reload("api")
+}
+
+func (x *Watcher) reload(by string) error {
+	x.mutex.Lock()
+	defer x.mutex.Unlock()
+
+	stat, err := os.Stat(x.path)
+	if err != nil {
+		return goerr.Wrap(err, "failed to stat config file", goerr.V("path", x.path))
+	}
+	// remember the file even if it is invalid not to report the same error repeatedly
+	x.modTime, x.size = stat.ModTime(), stat.Size()
+
+	data, err := os.ReadFile(x.path)
+	if err != nil {
+		return goerr.Wrap(err, "failed to read config file", goerr.V("path", x.path))
+	}
+
+	var opts Options
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&opts); err != nil {
+		return goerr.Wrap(err, "failed to parse config file", goerr.V("path", x.path))
+	}
+	r, err := opts.resolve()
+	if err != nil {
+		return goerr.Wrap(err, "invalid config file", goerr.V("path", x.path))
+	}
+
+	// the copy shares states such as clock, deduplicator and ring buffer with the base config
+	cfg := *x.base
+	cfg.levelWriters = append([]destination(nil), x.base.levelWriters...)
+	r.apply(&cfg, by)
+	cfg.finalize()
+
+	x.handler.live.Store(&cfg)
+	return nil
+}
+
+// changed returns true if modification time or size of the file is changed since the last reload.
+func (x *Watcher) changed() bool {
+	stat, err := os.Stat(x.path)
+	if err != nil {
+		return false
+	}
+
+	x.mutex.Lock()
+	defer x.mutex.Unlock()
+	return !stat.ModTime().Equal(x.modTime) || stat.Size() != x.size
+}
+
+func (x *Watcher) poll(interval time.Duration) {
+	defer close(x.done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-x.stop:
+			return
+		case <-ticker.C:
+		}
+
+		if !x.changed() {
+			continue
+		}
+
+		err := x.reload("config")
+		h := x.handler.current()
+		record := slog.NewRecord(h.cfg.clock.Now(), slog.LevelInfo, "log config reloaded", 0)
+		if err != nil {
+			record = slog.NewRecord(h.cfg.clock.Now(), slog.LevelError, "failed to reload log config", 0)
+			record.AddAttrs(slog.String("error", err.Error()))
+		}
+		record.AddAttrs(slog.String("path", x.path))
+		_ = h.announce(record)
+	}
+}
+
+// Close stops polling the file. The handler keeps the last configuration.
+func (x *Watcher) Close() error {
+	x.once.Do(func() {
+		close(x.stop)
+	})
+	<-x.done
+	return nil
+}
@@ -0,0 +1,144 @@
+package clog_test
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"text/template"
+	"time"
+
+	"github.com/m-mizutani/clog"
+	"github.com/m-mizutani/gt"
+)
+
+func writeConfigFile(t *testing.T, path, data string) {
+	t.Helper()
+	gt.NoError(t, os.WriteFile(path, []byte(data), 0644))
+}
+
+func TestWatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "clog.json")
+	writeConfigFile(t, path, `{"template": "standard", "color": false}`)
+
+	var buf syncBuffer
+	w := gt.R1(clog.Watch(path, 10*time.Millisecond, clog.WithWriter(&buf))).NoError(t)
+	t.Cleanup(func() { _ = w.Close() })
+
+	logger := slog.New(w.Handler()).With("user", "alice")
+	logger.Debug("hidden")
+	logger.Info("first")
+
+	writeConfigFile(t, path, `{"level": "debug", "template": "{{.Level}}: {{.Message}} ", "color": false}`)
+	deadline := time.Now().Add(5 * time.Second)
+	for !strings.Contains(buf.String(), "reloaded") && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	logger.Debug("second")
+
+	gt.S(t, buf.String()).Equal(strings.Join([]string{
+		`INFO first user="alice" `,
+		`INFO: log config reloaded path="` + path + `" `,
+		`DEBUG: second user="alice" `,
+		``,
+	}, "\n"))
+
+	if raceEnabled {
+		return
+	}
+	// the handler with the reloaded config is not allocated for every record
+	record := slog.NewRecord(time.Now(), slog.LevelDebug, "allocs", 0)
+	handle := func(h slog.Handler) float64 {
+		return testing.AllocsPerRun(100, func() {
+			_ = h.Handle(context.Background(), record)
+		})
+	}
+	plain := slog.New(clog.New(
+		clog.WithWriter(&syncBuffer{}),
+		clog.WithColor(false),
+		clog.WithTemplate(template.Must(template.New("plain").Parse("{{.Level}}: {{.Message}} "))),
+	)).With("user", "alice")
+	gt.N(t, handle(logger.Handler())).Equal(handle(plain.Handler()))
+}
+
+func TestWatchInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "clog.json")
+
+	t.Run("missing file", func(t *testing.T) {
+		_, err := clog.Watch(path, time.Second)
+		gt.Error(t, err)
+	})
+
+	t.Run("invalid interval", func(t *testing.T) {
+		writeConfigFile(t, path, `{}`)
+		_, err := clog.Watch(path, 0)
+		gt.Error(t, err)
+	})
+
+	t.Run("unknown field", func(t *testing.T) {
+		writeConfigFile(t, path, `{"colour": true}`)
+		_, err := clog.Watch(path, time.Second)
+		gt.Error(t, err)
+	})
+
+	t.Run("previous config is kept", func(t *testing.T) {
+		writeConfigFile(t, path, `{"template": "standard", "color": false}`)
+		var buf syncBuffer
+		w := gt.R1(clog.Watch(path, time.Hour, clog.WithWriter(&buf))).NoError(t)
+		t.Cleanup(func() { _ = w.Close() })
+
+		for _, data := range []string{
+			`{"printer": "fancy"}`,
+			`{"template": "plain"}`,
+			`{"template": "{{.Unknown}}"}`,
+			`{"level": "verbose"}`,
+			`{"color": "yes"}`,
+		} {
+			writeConfigFile(t, path, data)
+			gt.Error(t, w.Reload())
+		}
+
+		slog.New(w.Handler()).Info("hello")
+		gt.S(t, buf.String()).Equal("INFO hello \n")
+	})
+}
+
+func TestWatchPrinterAndSource(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "clog.json")
+	writeConfigFile(t, path, `{"template": "standard", "color": false}`)
+
+	var buf syncBuffer
+	w := gt.R1(clog.Watch(path, time.Hour, clog.WithWriter(&buf))).NoError(t)
+	t.Cleanup(func() { _ = w.Close() })
+	logger := slog.New(w.Handler())
+
+	logger.Info("linear", "id", 1)
+	writeConfigFile(t, path, `{"template": "standard", "color": false, "printer": "indent", "source": true}`)
+	gt.NoError(t, w.Reload())
+	logger.Info("indent", "id", 2)
+
+	out := buf.String()
+	gt.S(t, out).Contains("INFO linear id=1 \n")
+	gt.S(t, out).Contains("INFO [watch_test.go:")
+	gt.S(t, out).Contains("] indent \nid: 2\n")
+}
+
+func TestWatchLevelController(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "clog.json")
+	writeConfigFile(t, path, `{"level": "warn"}`)
+
+	ctrl := clog.NewLevelController(slog.LevelInfo)
+	var buf syncBuffer
+	w := gt.R1(clog.Watch(path, time.Hour,
+		clog.WithWriter(&buf),
+		clog.WithColor(false),
+		clog.WithTemplate(standardTmpl),
+		clog.WithLevel(ctrl),
+	)).NoError(t)
+	t.Cleanup(func() { _ = w.Close() })
+
+	gt.V(t, ctrl.Level()).Equal(slog.LevelWarn)
+	gt.S(t, buf.String()).Equal(`INFO log level changed from="INFO" to="WARN" by="api" ` + "\n")
+}
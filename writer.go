@@ -0,0 +1,77 @@
+package clog
+
+import (
+	"io"
+
+	"log/slog"
+
+	"github.com/mattn/go-isatty"
+)
+
+// ColorSupporter is implemented by writers that know whether ANSI color sequences should be written to them. For example, a writer of log files returns false to disable color output automatically.
+type ColorSupporter interface {
+	SupportsColor() bool
+}
+
+// destination is a writer that receives records of level minLevel and above.
+type destination struct {
+	minLevel slog.Level
+	w        io.Writer
+	color    bool
+	terminal bool
+}
+
+// isTerminal returns true if w is a terminal file.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(interface{ Fd() uintptr })
+	return ok && (isatty.IsTerminal(f.Fd()) || isatty.IsCygwinTerminal(f.Fd()))
+}
+
+// detectColor returns whether color output is enabled for w. The result is fallback unless w is a terminal file or implements ColorSupporter. Color is never enabled for w if fallback is false.
+func detectColor(w io.Writer, fallback bool) bool {
+	if !fallback {
+		return false
+	}
+
+	switch v := w.(type) {
+	case ColorSupporter:
+		return v.SupportsColor()
+	case interface{ Fd() uintptr }:
+		return isTerminal(w)
+	}
+	return fallback
+}
+
+// WithLevelWriter adds a writer that receives records of minLevel and above. A record is written to every matched level writer, and it is written to the writer of WithWriter only when no level writer matches. For example, WithLevelWriter(slog.LevelWarn, os.Stderr) sends WARN and ERROR to stderr and other records to stdout.
+// Color is enabled or disabled for each writer according to whether it is a terminal, unless WithColor is specified.
+func WithLevelWriter(minLevel slog.Level, w io.Writer) Option {
+	return func(cfg *config) {
+		cfg.levelWriters = append(cfg.levelWriters, destination{
+			minLevel: minLevel,
+			w:        w,
+		})
+	}
+}
+
+// newDestination returns a destination of w with color setting for w.
+func (x *config) newDestination(w io.Writer) destination {
+	dst := destination{w: w, color: x.enableColor, terminal: isTerminal(w)}
+	if !x.colorSpecified {
+		dst.color = detectColor(w, x.enableColor)
+	}
+	return dst
+}
+
+// destinations returns writers that should receive a record of the level.
+func (x *config) destinations(level slog.Level) []destination {
+	var matched []destination
+	for _, dst := range x.levelWriters {
+		if dst.minLevel <= level {
+			matched = append(matched, dst)
+		}
+	}
+	if len(matched) == 0 {
+		matched = append(matched, x.defaultDest)
+	}
+	return matched
+}
@@ -0,0 +1,145 @@
+package clog_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/m-mizutani/clog"
+	"github.com/m-mizutani/gt"
+)
+
+func TestLevelWriter(t *testing.T) {
+	var stdout, stderr, errBuf bytes.Buffer
+	logger := slog.New(clog.New(
+		clog.WithColor(false),
+		clog.WithLevel(slog.LevelDebug),
+		clog.WithWriter(&stdout),
+		clog.WithLevelWriter(slog.LevelWarn, &stderr),
+		clog.WithLevelWriter(slog.LevelError, &errBuf),
+	))
+
+	logger.Debug("debug message")
+	logger.Info("info message")
+	logger.Warn("warn message")
+	logger.Error("error message")
+
+	gt.S(t, stdout.String()).
+		Contains("debug message").
+		Contains("info message").
+		NotContains("warn message").
+		NotContains("error message")
+	gt.S(t, stderr.String()).
+		NotContains("info message").
+		Contains("warn message").
+		Contains("error message")
+	gt.S(t, errBuf.String()).
+		NotContains("warn message").
+		Contains("error message")
+}
+
+type colorWriter struct {
+	bytes.Buffer
+	supportsColor bool
+}
+
+func (x *colorWriter) SupportsColor() bool {
+	return x.supportsColor
+}
+
+func TestLevelWriterColor(t *testing.T) {
+	enableColorOutput(t)
+
+	t.Run("detected by writer", func(t *testing.T) {
+		colored := &colorWriter{supportsColor: true}
+		plain := &colorWriter{supportsColor: false}
+		logger := slog.New(clog.New(
+			clog.WithWriter(colored),
+			clog.WithLevelWriter(slog.LevelInfo, colored),
+			clog.WithLevelWriter(slog.LevelInfo, plain),
+		))
+		logger.Info("hello")
+
+		gt.S(t, colored.String()).Contains("\x1b[")
+		gt.S(t, plain.String()).
+			Contains("INFO hello").
+			NotContains("\x1b[")
+	})
+
+	t.Run("WithColor overrides detection", func(t *testing.T) {
+		plain := &colorWriter{supportsColor: false}
+		logger := slog.New(clog.New(
+			clog.WithColor(true),
+			clog.WithWriter(plain),
+		))
+		logger.Info("hello")
+
+		gt.S(t, plain.String()).Contains("\x1b[")
+	})
+}
+
+type countValuer struct {
+	calls *int
+}
+
+func (x countValuer) LogValue() slog.Value {
+	*x.calls++
+	return slog.IntValue(*x.calls)
+}
+
+func TestLevelWriterColorResolvesOnce(t *testing.T) {
+	enableColorOutput(t)
+
+	var hooked, deferred, valued int
+	colored := &colorWriter{supportsColor: true}
+	plain := &colorWriter{supportsColor: false}
+	logger := slog.New(clog.New(
+		clog.WithWriter(colored),
+		clog.WithLevelWriter(slog.LevelInfo, colored),
+		clog.WithLevelWriter(slog.LevelInfo, plain),
+		clog.WithTemplate(standardTmpl),
+		clog.WithAttrHook(func(groups []string, attr slog.Attr) *clog.HandleAttr {
+			if attr.Key != "user" {
+				return nil
+			}
+			hooked++
+			return &clog.HandleAttr{
+				Defer: func(w io.Writer) {
+					deferred++
+					fmt.Fprint(w, "deferred")
+				},
+			}
+		}),
+	))
+	logger.Info("hello", "user", "alice", "n", countValuer{calls: &valued})
+
+	gt.V(t, hooked).Equal(1)
+	gt.V(t, deferred).Equal(1)
+	gt.V(t, valued).Equal(1)
+	gt.S(t, colored.String()).Contains("\x1b[").Contains("deferred")
+	gt.S(t, plain.String()).Equal("INFO hello user=\"alice\" n=1 deferred\n")
+}
+
+type errWriter struct{}
+
+func (errWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("broken")
+}
+
+func TestLevelWriterError(t *testing.T) {
+	var buf bytes.Buffer
+	handler := clog.New(
+		clog.WithColor(false),
+		clog.WithLevelWriter(slog.LevelInfo, errWriter{}),
+		clog.WithLevelWriter(slog.LevelInfo, &buf),
+	)
+	err := handler.Handle(context.Background(), slog.NewRecord(time.Now(), slog.LevelInfo, "hello", 0))
+
+	gt.Error(t, err)
+	gt.S(t, buf.String()).Contains("hello")
+}